@@ -0,0 +1,294 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Parties are the kinds of organization on the channel. Which MSP IDs belong to
+// each party is kept in ledger state (OrgConfig), not in code.
+const (
+	PartySeller    = "seller"
+	PartyBuyer     = "buyer"
+	PartyRegistry  = "registry"
+	PartyLender    = "lender"
+	PartyJudiciary = "judiciary"
+)
+
+// Roles are carried in the "role" attribute of an identity's certificate, set
+// when the identity is registered with Fabric CA.
+const (
+	RoleSeller    = "seller"
+	RoleBuyer     = "buyer"
+	RoleRegistrar = "registrar"
+	RoleSurveyor  = "surveyor"
+	RoleAdmin     = "admin"
+	RoleLender    = "lender"
+	RoleJudge     = "judge"
+)
+
+// roleAttribute is the certificate attribute holding a caller's role
+const roleAttribute = "role"
+
+// adminOU is the organizational unit of an org's MSP admins when NodeOUs are
+// enabled, as in the test network's cryptogen identities
+const adminOU = "admin"
+
+// OrgConfig maps parties to MSP IDs. Until SetOrgConfig is first called the
+// defaults of the original three-org network apply.
+type OrgConfig struct {
+	Parties map[string][]string `json:"parties"` // party -> MSP IDs
+
+	// RolesOptional lets identities without any role attribute, such as
+	// cryptogen-generated users, act with their org's party alone
+	RolesOptional bool `json:"rolesOptional"`
+
+	// SuccessionObjectionDays is how long a succession stays open to objections;
+	// 0 means 30 days
+	SuccessionObjectionDays int `json:"successionObjectionDays,omitempty"`
+
+	// SaleConsentBasisPoints is the share of a jointly held land whose holders
+	// must consent to its sale; 0 means all of them
+	SaleConsentBasisPoints int `json:"saleConsentBasisPoints,omitempty"`
+}
+
+func defaultOrgConfig() *OrgConfig {
+	return &OrgConfig{
+		Parties: map[string][]string{
+			PartySeller:    {"Org1MSP"},
+			PartyBuyer:     {"Org2MSP"},
+			PartyRegistry:  {"Org3MSP"},
+			PartyLender:    {"Org1MSP", "Org2MSP"},
+			PartyJudiciary: {"Org3MSP"},
+		},
+	}
+}
+
+// accessRule says who may call a transaction: a member of one of the parties
+// holding one of the roles
+type accessRule struct {
+	parties []string
+	roles   []string
+	denied  string // completes "only ..." in the error message
+}
+
+// accessPolicy is the central authorization table. Checks that depend on the
+// record, such as being the current owner or the offer's buyer, are made by the
+// transactions themselves.
+var accessPolicy = map[string]accessRule{
+	"ListLand":            {[]string{PartySeller}, []string{RoleSeller}, "a Seller can list new land"},
+	"ManageLand":          {[]string{PartySeller, PartyBuyer}, []string{RoleSeller, RoleBuyer}, "a Seller or Buyer can manage land they own or act for"},
+	"SubmitSellerTitle":   {[]string{PartySeller}, []string{RoleSeller}, "a Seller can submit title documents"},
+	"GetAvailableLands":   {[]string{PartyBuyer}, []string{RoleBuyer}, "a Buyer can view available lands"},
+	"RequestToBuy":        {[]string{PartyBuyer}, []string{RoleBuyer}, "a Buyer can send requests"},
+	"WithdrawOffer":       {[]string{PartyBuyer}, []string{RoleBuyer}, "a Buyer can withdraw offers"},
+	"CommitBid":           {[]string{PartyBuyer}, []string{RoleBuyer}, "a Buyer can bid in auctions"},
+	"GetOffersForLand":    {[]string{PartyBuyer}, []string{RoleBuyer}, "the land owner or a Buyer can view offers"},
+	"VerifySellerTitle":   {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can verify seller title"},
+	"GetSellerTitle":      {[]string{PartyRegistry}, []string{RoleRegistrar, RoleSurveyor}, "the Seller or a LandRegistry officer can view seller title"},
+	"RegisterToBuyer":     {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can register land to buyer"},
+	"CloseAuction":        {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can close auctions"},
+	"SplitLand":           {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can split land"},
+	"MergeLands":          {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can merge lands"},
+	"CancelParcelRequest": {[]string{PartyRegistry}, []string{RoleRegistrar}, "the requesting owner or a LandRegistry registrar can cancel a parcel request"},
+	"GetOwnershipRecord":  {[]string{PartyRegistry}, []string{RoleRegistrar, RoleSurveyor}, "the owner or a LandRegistry officer can view the ownership record"},
+	"RegisterLien":        {[]string{PartyLender}, []string{RoleLender}, "a Lender can register liens"},
+	"FreezeLand":          {[]string{PartyJudiciary}, []string{RoleJudge}, "the Judiciary can freeze land"},
+	"UnfreezeLand":        {[]string{PartyJudiciary}, []string{RoleJudge}, "the Judiciary can unfreeze land"},
+	"InitiateSuccession":  {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can initiate successions"},
+	"ResolveObjection":    {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can resolve objections"},
+	"CancelSuccession":    {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can cancel successions"},
+	"FinalizeSuccession":  {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can finalize successions"},
+	"MigrateLandRecords":  {[]string{PartyRegistry}, []string{RoleAdmin}, "a LandRegistry admin can migrate land records"},
+	"AssignLegacyOwner":   {[]string{PartyRegistry}, []string{RoleRegistrar}, "a LandRegistry registrar can assign owners to legacy land"},
+	"SetOrgConfig":        {[]string{PartyRegistry}, []string{RoleAdmin}, "a LandRegistry admin can change the org configuration"},
+}
+
+// authorize checks the caller against the access policy for a transaction
+func authorize(ctx contractapi.TransactionContextInterface, txName string) error {
+	allowed, err := isAuthorized(ctx, txName)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("only %s", accessPolicy[txName].denied)
+	}
+	return nil
+}
+
+// isAuthorized reports whether the caller satisfies a transaction's access rule,
+// for transactions that also let record owners through
+func isAuthorized(ctx contractapi.TransactionContextInterface, txName string) (bool, error) {
+	rule, ok := accessPolicy[txName]
+	if !ok {
+		return false, fmt.Errorf("no access rule for %s", txName)
+	}
+
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if !config.inParty(msp, rule.parties...) {
+		return false, nil
+	}
+
+	role, found, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return false, fmt.Errorf("failed to read role attribute: %v", err)
+	}
+	if !found {
+		// An MSP admin without a role attribute, such as Admin@org3.example.com,
+		// may do admin work, so a network without Fabric CA roles can be
+		// configured in the first place
+		if contains(rule.roles, RoleAdmin) {
+			admin, err := callerIsMSPAdmin(ctx)
+			if err != nil || admin {
+				return admin, err
+			}
+		}
+		return config.RolesOptional, nil
+	}
+	return contains(rule.roles, role), nil
+}
+
+// callerIsMSPAdmin reports whether the caller's certificate carries the admin OU
+func callerIsMSPAdmin(ctx contractapi.TransactionContextInterface) (bool, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return false, fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	return contains(cert.Subject.OrganizationalUnit, adminOU), nil
+}
+
+// callerInParty reports whether the caller's org belongs to one of the parties
+func callerInParty(ctx contractapi.TransactionContextInterface, parties ...string) (bool, error) {
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	return config.inParty(msp, parties...), nil
+}
+
+// requireMSP checks that an MSP ID given as an argument belongs to one of the
+// parties, so it can safely be put into a parcel's endorsement policy
+func (config *OrgConfig) requireMSP(msp string, parties ...string) error {
+	if !config.inParty(msp, parties...) {
+		return fmt.Errorf("MSP %s is not an org of the %s party", msp, strings.Join(parties, " or "))
+	}
+	return nil
+}
+
+func (config *OrgConfig) inParty(msp string, parties ...string) bool {
+	for _, party := range parties {
+		if contains(config.Parties[party], msp) {
+			return true
+		}
+	}
+	return false
+}
+
+// Land Registry admin (Org3) sets which MSP IDs act as seller, buyer, registry,
+// lender and judiciary. configJSON is an OrgConfig; seller, buyer and registry
+// need at least one MSP ID, and an omitted lender or judiciary party keeps its
+// default.
+func (c *LandContract) SetOrgConfig(ctx contractapi.TransactionContextInterface, configJSON string) error {
+	err := authorize(ctx, "SetOrgConfig")
+	if err != nil {
+		return err
+	}
+
+	var config OrgConfig
+	err = json.Unmarshal([]byte(configJSON), &config)
+	if err != nil {
+		return fmt.Errorf("failed to parse org config: %v", err)
+	}
+	for _, party := range []string{PartySeller, PartyBuyer, PartyRegistry} {
+		if len(config.Parties[party]) == 0 {
+			return fmt.Errorf("org config must list at least one MSP ID for party %s", party)
+		}
+	}
+	if config.SuccessionObjectionDays < 0 {
+		return fmt.Errorf("successionObjectionDays must not be negative")
+	}
+	if config.SaleConsentBasisPoints < 0 || config.SaleConsentBasisPoints > fullShare {
+		return fmt.Errorf("saleConsentBasisPoints must be between 0 and %d", fullShare)
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal org config: %v", err)
+	}
+	key, err := orgConfigKey(ctx)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, configBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store org config: %v", err)
+	}
+
+	return emitEvent(ctx, EventOrgConfigUpdated, LandEvent{})
+}
+
+// Anyone reads the org configuration in force
+func (c *LandContract) GetOrgConfig(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+	return readOrgConfig(ctx)
+}
+
+// readOrgConfig loads the org configuration, falling back to the defaults
+func readOrgConfig(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+	key, err := orgConfigKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org config: %v", err)
+	}
+	if configBytes == nil {
+		return defaultOrgConfig(), nil
+	}
+
+	var config OrgConfig
+	err = json.Unmarshal(configBytes, &config)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling org config: %v", err)
+	}
+	// Configs stored before a party existed get its default orgs
+	for party, msps := range defaultOrgConfig().Parties {
+		if _, ok := config.Parties[party]; !ok {
+			config.Parties[party] = msps
+		}
+	}
+	return &config, nil
+}
+
+func orgConfigKey(ctx contractapi.TransactionContextInterface) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("config", []string{"orgs"})
+	if err != nil {
+		return "", fmt.Errorf("failed to create org config key: %v", err)
+	}
+	return key, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
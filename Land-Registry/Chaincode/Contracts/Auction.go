@@ -0,0 +1,820 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AuctionContract runs time-boxed sealed-bid auctions for land. Buyers commit the
+// hash of their bid on the public ledger and keep the bid in collectionBuyerSeller;
+// once bidding ends they reveal it, and the highest revealed bid becomes the land's
+// accepted offer for RegisterToBuyer.
+type AuctionContract struct {
+	contractapi.Contract
+}
+
+const (
+	AuctionOpen   = "Open"
+	AuctionClosed = "Closed"
+)
+
+// auctionSettlementPeriod is how long the winning bid stays valid for the registry transfer
+const auctionSettlementPeriod = 30 * 24 * time.Hour
+
+// minSaltLength keeps bid hashes from being brute-forced over likely prices
+const minSaltLength = 16
+
+// Auction is the public record of a sealed-bid auction. Deadlines are judged
+// against the transaction timestamp.
+type Auction struct {
+	AuctionID         string `json:"auctionID"`
+	LandID            string `json:"landID"`
+	Seller            string `json:"seller"` // client identity ID of the owner who started it
+	ReservePriceMinor int64  `json:"reservePriceMinor"`
+	BiddingEnds       string `json:"biddingEnds"` // RFC3339; bids are committed before this
+	RevealEnds        string `json:"revealEnds"`  // RFC3339; bids are revealed before this
+	Status            string `json:"status"`      // Open, Closed
+	WinningBidID      string `json:"winningBidID,omitempty"`
+	WinningPriceMinor int64  `json:"winningPriceMinor,omitempty"`
+}
+
+// BidCommitment is the public side of a bid: who bid and the hash of the sealed bid.
+// The price becomes public only when the bidder reveals it.
+type BidCommitment struct {
+	AuctionID   string `json:"auctionID"`
+	BidID       string `json:"bidID"`
+	BuyerID     string `json:"buyerID"`
+	Hash        string `json:"hash"` // hex SHA-256 of the sealed bid as stored
+	CommittedAt string `json:"committedAt"`
+	Revealed    bool   `json:"revealed"`
+	PriceMinor  int64  `json:"priceMinor,omitempty"` // set once revealed
+}
+
+// SealedBid is a bid as kept in collectionBuyerSeller
+type SealedBid struct {
+	DocType     string `json:"docType"`
+	AuctionID   string `json:"auctionID"`
+	BidID       string `json:"bidID"`
+	LandID      string `json:"landID"`
+	BuyerID     string `json:"buyerID"`
+	BuyerMSP    string `json:"buyerMSP"`
+	BuyerName   string `json:"buyerName"`
+	Aadhar      string `json:"aadhar"`
+	PriceMinor  int64  `json:"priceMinor"`
+	Salt        string `json:"salt"`
+	CommittedAt string `json:"committedAt"`
+}
+
+// Seller (current owner or their attorney) puts a listed land up for a sealed-bid auction. Bids are
+// committed until biddingEnds and revealed until revealEnds, both RFC3339.
+func (a *AuctionContract) StartAuction(ctx contractapi.TransactionContextInterface, auctionID string, landID string, reservePriceMinor int64, biddingEnds string, revealEnds string) error {
+	if auctionID == "" {
+		return fmt.Errorf("auctionID is required")
+	}
+	if reservePriceMinor <= 0 {
+		return fmt.Errorf("reservePriceMinor must be a positive amount in minor currency units")
+	}
+
+	err := authorize(ctx, "ManageLand")
+	if err != nil {
+		return err
+	}
+	land, agent, err := readManagedLand(ctx, "StartAuction", landID)
+	if err != nil {
+		return err
+	}
+	if land.Status != StatusForSale {
+		return fmt.Errorf("land with ID %s is not listed for sale", landID)
+	}
+	if land.AcceptedOfferID != "" {
+		return fmt.Errorf("land with ID %s already has an accepted offer", landID)
+	}
+	err = requireNotFrozen(ctx, landID)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	bidding, err := time.Parse(time.RFC3339, biddingEnds)
+	if err != nil {
+		return fmt.Errorf("biddingEnds must be an RFC3339 timestamp: %v", err)
+	}
+	reveal, err := time.Parse(time.RFC3339, revealEnds)
+	if err != nil {
+		return fmt.Errorf("revealEnds must be an RFC3339 timestamp: %v", err)
+	}
+	if !bidding.After(now) {
+		return fmt.Errorf("biddingEnds %s is already in the past", biddingEnds)
+	}
+	if !reveal.After(bidding) {
+		return fmt.Errorf("revealEnds must be after biddingEnds")
+	}
+
+	key, err := auctionKey(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read auction: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("auction with ID %s already exists", auctionID)
+	}
+
+	auction := Auction{
+		AuctionID:         auctionID,
+		LandID:            landID,
+		Seller:            land.Owner,
+		ReservePriceMinor: reservePriceMinor,
+		BiddingEnds:       bidding.UTC().Format(time.RFC3339),
+		RevealEnds:        reveal.UTC().Format(time.RFC3339),
+		Status:            AuctionOpen,
+	}
+	err = putAuction(ctx, &auction)
+	if err != nil {
+		return err
+	}
+
+	land.Status = StatusInAuction
+	land.AuctionID = auctionID
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.AuctionID = auctionID
+	event.Agent = agent
+	return emitEvent(ctx, EventAuctionStarted, event)
+}
+
+// Buyer (Org2) commits a sealed bid before bidding ends. The bid is passed as
+// "bid" in transient data: {"priceMinor", "salt", "buyerName", "aadhar"}. Only
+// its hash is public; each buyer bids once per auction.
+func (a *AuctionContract) CommitBid(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) error {
+	err := authorize(ctx, "CommitBid")
+	if err != nil {
+		return err
+	}
+	if bidID == "" {
+		return fmt.Errorf("bidID is required")
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+
+	auction, err := readAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if auction.Status != AuctionOpen || !now.Before(deadline(auction.BiddingEnds)) {
+		return fmt.Errorf("bidding for auction %s has ended", auctionID)
+	}
+
+	buyerID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if buyerID == auction.Seller {
+		return fmt.Errorf("only a buyer other than the seller can bid in auction %s", auctionID)
+	}
+
+	commitments, err := readCommitments(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	for _, commitment := range commitments {
+		if commitment.BidID == bidID {
+			return fmt.Errorf("bid with ID %s already exists", bidID)
+		}
+		if commitment.BuyerID == buyerID {
+			return fmt.Errorf("buyer has already bid in auction %s", auctionID)
+		}
+	}
+	// The winning bid becomes an offer with the same ID, so the ID must be free there too
+	offerHash, err := ctx.GetStub().GetPrivateDataHash(collectionBuyerSeller, bidID)
+	if err != nil {
+		return fmt.Errorf("failed to read offer hash: %v", err)
+	}
+	if offerHash != nil {
+		return fmt.Errorf("offer with ID %s already exists", bidID)
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient data: %v", err)
+	}
+	bidData, ok := transient["bid"]
+	if !ok {
+		return fmt.Errorf("bid key missing in transient data")
+	}
+	var request struct {
+		PriceMinor int64  `json:"priceMinor"`
+		Salt       string `json:"salt"`
+		BuyerName  string `json:"buyerName"`
+		Aadhar     string `json:"aadhar"`
+	}
+	err = json.Unmarshal(bidData, &request)
+	if err != nil {
+		return fmt.Errorf("failed to parse bid: %v", err)
+	}
+	if request.PriceMinor <= 0 {
+		return fmt.Errorf("priceMinor must be a positive amount in minor currency units")
+	}
+	if len(request.Salt) < minSaltLength {
+		return fmt.Errorf("salt must be at least %d characters", minSaltLength)
+	}
+
+	bid := SealedBid{
+		DocType:     "bid",
+		AuctionID:   auctionID,
+		BidID:       bidID,
+		LandID:      auction.LandID,
+		BuyerID:     buyerID,
+		BuyerMSP:    msp,
+		BuyerName:   request.BuyerName,
+		Aadhar:      request.Aadhar,
+		PriceMinor:  request.PriceMinor,
+		Salt:        request.Salt,
+		CommittedAt: now.Format(time.RFC3339),
+	}
+	bidJSON, err := json.Marshal(bid)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bid: %v", err)
+	}
+	key, err := bidKey(ctx, auctionID, bidID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutPrivateData(collectionBuyerSeller, key, bidJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store bid: %v", err)
+	}
+
+	hash := sha256.Sum256(bidJSON)
+	commitment := BidCommitment{
+		AuctionID:   auctionID,
+		BidID:       bidID,
+		BuyerID:     buyerID,
+		Hash:        hex.EncodeToString(hash[:]),
+		CommittedAt: bid.CommittedAt,
+	}
+	err = putCommitment(ctx, &commitment)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventBidCommitted, LandEvent{LandID: auction.LandID, AuctionID: auctionID, BidID: bidID})
+}
+
+// Buyer who made a bid reveals it after bidding ends and before revealEnds. The
+// sealed bid, as returned by GetBid, is passed as "bid" in transient data and
+// must match the commitment; its price then becomes public.
+func (a *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) error {
+	auction, err := readAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	commitment, err := readCommitment(ctx, auctionID, bidID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if commitment.BuyerID != clientID {
+		return fmt.Errorf("only the buyer who made bid %s can reveal it", bidID)
+	}
+	if commitment.Revealed {
+		return fmt.Errorf("bid %s has already been revealed", bidID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if now.Before(deadline(auction.BiddingEnds)) {
+		return fmt.Errorf("bidding for auction %s is still open", auctionID)
+	}
+	if auction.Status != AuctionOpen || !now.Before(deadline(auction.RevealEnds)) {
+		return fmt.Errorf("reveal period for auction %s has ended", auctionID)
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient data: %v", err)
+	}
+	bidData, ok := transient["bid"]
+	if !ok {
+		return fmt.Errorf("bid key missing in transient data")
+	}
+	bid, err := verifyBid(bidData, commitment)
+	if err != nil {
+		return err
+	}
+
+	commitment.Revealed = true
+	commitment.PriceMinor = bid.PriceMinor
+	err = putCommitment(ctx, commitment)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventBidRevealed, LandEvent{LandID: auction.LandID, AuctionID: auctionID, BidID: bidID})
+}
+
+// Seller (current owner), a LandRegistry registrar or any bidder closes the
+// auction once the reveal period is over, so a seller cannot hold a won auction
+// open. The highest revealed bid at or above the reserve wins, earliest commitment
+// first on a tie, and becomes the land's accepted offer, so the registry transfers
+// the land with RegisterToBuyer as for any sale. Without a winner the land simply
+// returns to sale.
+//
+// The closer passes the winning bid as "winningBid" in transient data (see
+// GetWinningBid) when they can read it. A registrar or losing bidder cannot; the
+// winner is then recorded from its public commitment, and the seller or winner
+// completes the offer with RecordWinningBid.
+func (a *AuctionContract) CloseAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	auction, err := readAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	if auction.Status != AuctionOpen {
+		return fmt.Errorf("auction %s is already closed", auctionID)
+	}
+	land, err := readLand(ctx, auction.LandID)
+	if err != nil {
+		return err
+	}
+	agent, err := requireAuctionCloser(ctx, auction, land)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if now.Before(deadline(auction.RevealEnds)) {
+		return fmt.Errorf("reveal period for auction %s is still open", auctionID)
+	}
+
+	winner, err := winningCommitment(ctx, auction)
+	if err != nil {
+		return err
+	}
+
+	if winner != nil {
+		expiry := now.Add(auctionSettlementPeriod).Format(time.RFC3339)
+
+		transient, err := ctx.GetStub().GetTransient()
+		if err != nil {
+			return fmt.Errorf("error getting transient data: %v", err)
+		}
+		if bidData, ok := transient["winningBid"]; ok {
+			bid, err := verifyBid(bidData, winner)
+			if err != nil {
+				return err
+			}
+			err = putOffer(ctx, winningOffer(bid, expiry))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = setLandPrice(land, winner.PriceMinor)
+		if err != nil {
+			return err
+		}
+		land.AcceptedOfferID = winner.BidID
+		land.AcceptedExpiry = expiry
+		auction.WinningBidID = winner.BidID
+		auction.WinningPriceMinor = winner.PriceMinor
+	}
+
+	auction.Status = AuctionClosed
+	err = putAuction(ctx, auction)
+	if err != nil {
+		return err
+	}
+
+	land.Status = StatusForSale
+	land.AuctionID = ""
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.AuctionID = auctionID
+	event.OfferID = auction.WinningBidID
+	event.Agent = agent
+	return emitEvent(ctx, EventAuctionClosed, event)
+}
+
+// Seller or winning bidder records the winning bid as the land's accepted offer
+// when the auction was closed by someone who could not present it. The bid is
+// passed as "winningBid" in transient data and checked against its commitment.
+func (a *AuctionContract) RecordWinningBid(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	auction, err := readAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	if auction.Status != AuctionClosed || auction.WinningBidID == "" {
+		return fmt.Errorf("auction %s has no winning bid to record", auctionID)
+	}
+	land, err := readLand(ctx, auction.LandID)
+	if err != nil {
+		return err
+	}
+	if land.AcceptedOfferID != auction.WinningBidID {
+		return fmt.Errorf("winning bid %s is no longer the accepted offer for land %s", auction.WinningBidID, land.LandID)
+	}
+	storedHash, err := ctx.GetStub().GetPrivateDataHash(collectionBuyerSeller, auction.WinningBidID)
+	if err != nil {
+		return fmt.Errorf("failed to read offer hash: %v", err)
+	}
+	if storedHash != nil {
+		return fmt.Errorf("winning bid %s is already recorded", auction.WinningBidID)
+	}
+
+	commitment, err := readCommitment(ctx, auctionID, auction.WinningBidID)
+	if err != nil {
+		return err
+	}
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient data: %v", err)
+	}
+	bidData, ok := transient["winningBid"]
+	if !ok {
+		return fmt.Errorf("winningBid key missing in transient data")
+	}
+	bid, err := verifyBid(bidData, commitment)
+	if err != nil {
+		return err
+	}
+
+	// The presented bid proves the caller could read it: only its bidder and the
+	// seller can. The offer's expiry is the one set when the auction closed.
+	err = putOffer(ctx, winningOffer(bid, land.AcceptedExpiry))
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventWinningBidRecorded, LandEvent{LandID: land.LandID, AuctionID: auctionID, OfferID: bid.BidID})
+}
+
+// Anyone reads an auction
+func (a *AuctionContract) GetAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+	return readAuction(ctx, auctionID)
+}
+
+// Anyone lists an auction's bid commitments, with prices of revealed bids
+func (a *AuctionContract) GetAuctionBids(ctx contractapi.TransactionContextInterface, auctionID string) ([]*BidCommitment, error) {
+	if _, err := readAuction(ctx, auctionID); err != nil {
+		return nil, err
+	}
+	return readCommitments(ctx, auctionID)
+}
+
+// Buyer who made a bid reads it, e.g. to reveal it; the seller can read it once
+// it has been revealed. Must be evaluated on a peer of Org1 or Org2.
+func (a *AuctionContract) GetBid(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) (*SealedBid, error) {
+	auction, err := readAuction(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	commitment, err := readCommitment(ctx, auctionID, bidID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if commitment.BuyerID != clientID && (clientID != auction.Seller || !commitment.Revealed) {
+		return nil, fmt.Errorf("only the bidder, or the seller once it is revealed, can view bid %s", bidID)
+	}
+
+	return readBid(ctx, auctionID, bidID)
+}
+
+// Seller or winning bidder reads the winning bid after the reveal period, to pass
+// to CloseAuction or RecordWinningBid. It returns nothing when no revealed bid
+// meets the reserve. Must be evaluated on a peer of Org1 or Org2.
+func (a *AuctionContract) GetWinningBid(ctx contractapi.TransactionContextInterface, auctionID string) (*SealedBid, error) {
+	auction, err := readAuction(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(deadline(auction.RevealEnds)) {
+		return nil, fmt.Errorf("reveal period for auction %s is still open", auctionID)
+	}
+
+	winner, err := winningCommitment(ctx, auction)
+	if err != nil || winner == nil {
+		return nil, err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if clientID != auction.Seller && clientID != winner.BuyerID {
+		return nil, fmt.Errorf("only the seller or the winning bidder can view the winning bid of auction %s", auctionID)
+	}
+	return readBid(ctx, auctionID, winner.BidID)
+}
+
+// requireAuctionCloser lets the land's owner or their attorney, a registrar or a
+// bidder in the auction close it. It returns the agent's ID when an attorney does.
+func requireAuctionCloser(ctx contractapi.TransactionContextInterface, auction *Auction, land *Land) (string, error) {
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if clientID == land.Owner {
+		return "", nil
+	}
+	registrar, err := isAuthorized(ctx, "CloseAuction")
+	if err != nil || registrar {
+		return "", err
+	}
+	commitments, err := readCommitments(ctx, auction.AuctionID)
+	if err != nil {
+		return "", err
+	}
+	for _, commitment := range commitments {
+		if commitment.BuyerID == clientID {
+			return "", nil
+		}
+	}
+	manager, err := isAuthorized(ctx, "ManageLand")
+	if err != nil {
+		return "", err
+	}
+	if manager {
+		grant, err := actingAgent(ctx, "CloseAuction", land.LandID, land.Owner)
+		if err != nil {
+			return "", err
+		}
+		if grant != nil {
+			return grant.Agent, nil
+		}
+	}
+	return "", fmt.Errorf("only the owner or their attorney, a bidder or %s", accessPolicy["CloseAuction"].denied)
+}
+
+// winningOffer is the accepted offer recorded for a winning bid. It is only
+// written, never read, so peers outside collectionBuyerSeller can endorse it.
+func winningOffer(bid *SealedBid, expiry string) *Offer {
+	return &Offer{
+		DocType:    "offer",
+		OfferID:    bid.BidID,
+		LandID:     bid.LandID,
+		BuyerID:    bid.BuyerID,
+		BuyerMSP:   bid.BuyerMSP,
+		BuyerName:  bid.BuyerName,
+		Aadhar:     bid.Aadhar,
+		PriceMinor: bid.PriceMinor,
+		Expiry:     expiry,
+		CreatedAt:  bid.CommittedAt,
+		Status:     OfferAccepted,
+	}
+}
+
+// winningCommitment picks the highest revealed bid at or above the reserve, or nil.
+// Ties go to the earliest commitment, then the lowest bid ID.
+func winningCommitment(ctx contractapi.TransactionContextInterface, auction *Auction) (*BidCommitment, error) {
+	commitments, err := readCommitments(ctx, auction.AuctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var eligible []*BidCommitment
+	for _, commitment := range commitments {
+		if commitment.Revealed && commitment.PriceMinor >= auction.ReservePriceMinor {
+			eligible = append(eligible, commitment)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(eligible, func(i, j int) bool {
+		if eligible[i].PriceMinor != eligible[j].PriceMinor {
+			return eligible[i].PriceMinor > eligible[j].PriceMinor
+		}
+		if eligible[i].CommittedAt != eligible[j].CommittedAt {
+			return eligible[i].CommittedAt < eligible[j].CommittedAt
+		}
+		return eligible[i].BidID < eligible[j].BidID
+	})
+	return eligible[0], nil
+}
+
+// verifyBid checks a presented sealed bid against its public commitment. Bids are
+// always stored as json.Marshal(SealedBid), so re-marshaling yields the committed bytes.
+func verifyBid(bidData []byte, commitment *BidCommitment) (*SealedBid, error) {
+	var bid SealedBid
+	decoder := json.NewDecoder(bytes.NewReader(bidData))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&bid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bid: %v", err)
+	}
+	if bid.AuctionID != commitment.AuctionID || bid.BidID != commitment.BidID {
+		return nil, fmt.Errorf("presented bid is not bid %s of auction %s", commitment.BidID, commitment.AuctionID)
+	}
+
+	canonical, err := json.Marshal(bid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bid: %v", err)
+	}
+	hash := sha256.Sum256(canonical)
+	if hex.EncodeToString(hash[:]) != commitment.Hash {
+		return nil, fmt.Errorf("bid %s does not match its commitment", commitment.BidID)
+	}
+
+	return &bid, nil
+}
+
+// readAuction loads an auction from the public ledger
+func readAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+	key, err := auctionKey(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	auctionBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auction: %v", err)
+	}
+	if auctionBytes == nil {
+		return nil, fmt.Errorf("auction with ID %s does not exist", auctionID)
+	}
+
+	var auction Auction
+	err = json.Unmarshal(auctionBytes, &auction)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling auction: %v", err)
+	}
+	return &auction, nil
+}
+
+// putAuction writes an auction to the public ledger
+func putAuction(ctx contractapi.TransactionContextInterface, auction *Auction) error {
+	auctionJSON, err := json.Marshal(auction)
+	if err != nil {
+		return fmt.Errorf("failed to marshal auction: %v", err)
+	}
+	key, err := auctionKey(ctx, auction.AuctionID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store auction: %v", err)
+	}
+	return nil
+}
+
+// readCommitment loads one bid commitment from the public ledger
+func readCommitment(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) (*BidCommitment, error) {
+	key, err := commitmentKey(ctx, auctionID, bidID)
+	if err != nil {
+		return nil, err
+	}
+	commitmentBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bid commitment: %v", err)
+	}
+	if commitmentBytes == nil {
+		return nil, fmt.Errorf("bid with ID %s does not exist in auction %s", bidID, auctionID)
+	}
+
+	var commitment BidCommitment
+	err = json.Unmarshal(commitmentBytes, &commitment)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling bid commitment: %v", err)
+	}
+	return &commitment, nil
+}
+
+// readCommitments returns every bid commitment of an auction
+func readCommitments(ctx contractapi.TransactionContextInterface, auctionID string) ([]*BidCommitment, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("auctionbid", []string{auctionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bid commitments: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	commitments := []*BidCommitment{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var commitment BidCommitment
+		err = json.Unmarshal(queryResponse.Value, &commitment)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling bid commitment: %v", err)
+		}
+		commitments = append(commitments, &commitment)
+	}
+	return commitments, nil
+}
+
+// putCommitment writes a bid commitment to the public ledger
+func putCommitment(ctx contractapi.TransactionContextInterface, commitment *BidCommitment) error {
+	commitmentJSON, err := json.Marshal(commitment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bid commitment: %v", err)
+	}
+	key, err := commitmentKey(ctx, commitment.AuctionID, commitment.BidID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, commitmentJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store bid commitment: %v", err)
+	}
+	return nil
+}
+
+// readBid loads a sealed bid from the Buyer/Seller private collection
+func readBid(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) (*SealedBid, error) {
+	key, err := bidKey(ctx, auctionID, bidID)
+	if err != nil {
+		return nil, err
+	}
+	bidBytes, err := ctx.GetStub().GetPrivateData(collectionBuyerSeller, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bid: %v", err)
+	}
+	if bidBytes == nil {
+		return nil, fmt.Errorf("bid with ID %s does not exist in auction %s", bidID, auctionID)
+	}
+
+	var bid SealedBid
+	err = json.Unmarshal(bidBytes, &bid)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling bid: %v", err)
+	}
+	return &bid, nil
+}
+
+func auctionKey(ctx contractapi.TransactionContextInterface, auctionID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("auction", []string{auctionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create auction key: %v", err)
+	}
+	return key, nil
+}
+
+func commitmentKey(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("auctionbid", []string{auctionID, bidID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create bid commitment key: %v", err)
+	}
+	return key, nil
+}
+
+func bidKey(ctx contractapi.TransactionContextInterface, auctionID string, bidID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("bid", []string{auctionID, bidID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create bid key: %v", err)
+	}
+	return key, nil
+}
+
+// deadline reads a time the contract itself stored in RFC3339
+func deadline(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339, value)
+	return t
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// startAuction puts a listed land up for an auction with an hour of bidding and
+// an hour of reveals
+func (l *testLedger) startAuction(auctionID string, landID string, reservePriceMinor int64) {
+	l.t.Helper()
+	auctions := &AuctionContract{}
+	l.must(auctions.StartAuction(l.as(l.seller, nil), auctionID, landID, reservePriceMinor,
+		l.now.Add(time.Hour).Format(time.RFC3339), l.now.Add(2*time.Hour).Format(time.RFC3339)))
+}
+
+// sealedBid returns transient data for a bid at priceMinor
+func (l *testLedger) sealedBid(priceMinor int64) map[string][]byte {
+	l.t.Helper()
+	bid, err := json.Marshal(map[string]interface{}{
+		"priceMinor": priceMinor,
+		"salt":       "0123456789abcdef",
+		"buyerName":  "Bidder",
+		"aadhar":     "123412341234",
+	})
+	l.must(err)
+	return map[string][]byte{"bid": bid}
+}
+
+// storedBid returns a bid as its bidder reads it back, under the given transient key
+func (l *testLedger) storedBid(bidder *testIdentity, auctionID string, bidID string, name string) map[string][]byte {
+	l.t.Helper()
+	auctions := &AuctionContract{}
+	bid, err := auctions.GetBid(l.as(bidder, nil), auctionID, bidID)
+	l.must(err)
+	bidJSON, err := json.Marshal(bid)
+	l.must(err)
+	return map[string][]byte{name: bidJSON}
+}
+
+func TestAuctionTieGoesToEarliestBid(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	auctions := &AuctionContract{}
+	l.listLand("L1")
+	land := l.land("L1")
+	land.TitleVerified = true
+	l.putLand(land)
+	bidder2 := newTestIdentity(t, "bidder2", "Org2MSP", RoleBuyer)
+	bidder3 := newTestIdentity(t, "bidder3", "Org2MSP", RoleBuyer)
+
+	l.startAuction("A1", "L1", 50000000)
+	l.must(auctions.CommitBid(l.as(l.buyer, l.sealedBid(60000000)), "A1", "B1"))
+	l.advance(time.Minute)
+	l.must(auctions.CommitBid(l.as(bidder2, l.sealedBid(60000000)), "A1", "B2"))
+	l.mustFail(auctions.CommitBid(l.as(bidder2, l.sealedBid(70000000)), "A1", "B3"), "buyer has already bid in auction A1")
+	l.mustFail(auctions.RevealBid(l.as(l.buyer, l.storedBid(l.buyer, "A1", "B1", "bid")), "A1", "B1"), "bidding for auction A1 is still open")
+
+	l.advance(time.Hour)
+	l.mustFail(auctions.CommitBid(l.as(bidder3, l.sealedBid(90000000)), "A1", "B3"), "bidding for auction A1 has ended")
+
+	var tampered SealedBid
+	l.must(json.Unmarshal(l.storedBid(l.buyer, "A1", "B1", "bid")["bid"], &tampered))
+	tampered.PriceMinor = 90000000
+	tamperedJSON, err := json.Marshal(tampered)
+	l.must(err)
+	l.mustFail(auctions.RevealBid(l.as(l.buyer, map[string][]byte{"bid": tamperedJSON}), "A1", "B1"), "bid B1 does not match its commitment")
+	l.mustFail(auctions.RevealBid(l.as(bidder2, l.storedBid(l.buyer, "A1", "B1", "bid")), "A1", "B1"), "only the buyer who made bid B1 can reveal it")
+	l.must(auctions.RevealBid(l.as(l.buyer, l.storedBid(l.buyer, "A1", "B1", "bid")), "A1", "B1"))
+	l.must(auctions.RevealBid(l.as(bidder2, l.storedBid(bidder2, "A1", "B2", "bid")), "A1", "B2"))
+	l.mustFail(auctions.CloseAuction(l.as(l.registrar, nil), "A1"), "reveal period for auction A1 is still open")
+
+	l.advance(time.Hour)
+	l.mustFail(auctions.CloseAuction(l.as(bidder3, nil), "A1"), "only the owner or their attorney, a bidder or")
+	l.must(auctions.CloseAuction(l.as(l.registrar, nil), "A1"))
+	auction, err := auctions.GetAuction(l.as(l.registrar, nil), "A1")
+	l.must(err)
+	if auction.WinningBidID != "B1" || auction.WinningPriceMinor != 60000000 {
+		t.Fatalf("auction won by %s at %d, want B1 at 60000000", auction.WinningBidID, auction.WinningPriceMinor)
+	}
+	if land := l.land("L1"); land.Status != StatusForSale || land.AcceptedOfferID != "B1" || land.PriceMinor != 60000000 {
+		t.Fatalf("land is %s with accepted offer %q at %d after the auction", land.Status, land.AcceptedOfferID, land.PriceMinor)
+	}
+
+	// The registrar could not present the winning bid, so the winner records it
+	_, err = contract.RegisterToBuyer(l.as(l.registrar, map[string][]byte{"documentHash": []byte("deed")}), "L1")
+	l.mustFail(err, "acceptedOffer key missing in transient")
+	l.mustFail(auctions.RecordWinningBid(l.as(bidder2, l.storedBid(bidder2, "A1", "B2", "winningBid")), "A1"), "presented bid is not bid B1 of auction A1")
+	l.must(auctions.RecordWinningBid(l.as(l.buyer, l.storedBid(l.buyer, "A1", "B1", "winningBid")), "A1"))
+	l.mustFail(auctions.RecordWinningBid(l.as(l.buyer, l.storedBid(l.buyer, "A1", "B1", "winningBid")), "A1"), "winning bid B1 is already recorded")
+	_, err = contract.RegisterToBuyer(l.as(l.registrar, map[string][]byte{
+		"acceptedOffer": l.privateJSON(collectionBuyerSeller, "B1"),
+		"documentHash":  []byte("deed"),
+	}), "L1")
+	l.must(err)
+
+	if land := l.land("L1"); land.Owner != "buyer" || land.Status != StatusSold || land.AcceptedOfferID != "" {
+		t.Fatalf("land is %s, owned by %s with accepted offer %q after registration", land.Status, land.Owner, land.AcceptedOfferID)
+	}
+	var record BuyerOwnership
+	l.must(json.Unmarshal(l.privateJSON(collectionBuyerLandRegistry, "L1"), &record))
+	if record.SellingPrice != "600000.00" {
+		t.Fatalf("ownership record has selling price %q, want 600000.00", record.SellingPrice)
+	}
+}
+
+func TestAuctionBelowReserveReturnsLandToSale(t *testing.T) {
+	l := newTestLedger(t)
+	auctions := &AuctionContract{}
+	l.listLand("L1")
+
+	l.startAuction("A1", "L1", 50000000)
+	l.must(auctions.CommitBid(l.as(l.buyer, l.sealedBid(40000000)), "A1", "B1"))
+	l.advance(time.Hour)
+	l.must(auctions.RevealBid(l.as(l.buyer, l.storedBid(l.buyer, "A1", "B1", "bid")), "A1", "B1"))
+	l.advance(time.Hour)
+
+	winner, err := auctions.GetWinningBid(l.as(l.seller, nil), "A1")
+	l.must(err)
+	if winner != nil {
+		t.Fatalf("bid below the reserve won: %+v", winner)
+	}
+	l.must(auctions.CloseAuction(l.as(l.buyer, nil), "A1"))
+	if land := l.land("L1"); land.Status != StatusForSale || land.AuctionID != "" || land.AcceptedOfferID != "" || land.PriceMinor != 100000000 {
+		t.Fatalf("land is %s in auction %q with accepted offer %q at %d", land.Status, land.AuctionID, land.AcceptedOfferID, land.PriceMinor)
+	}
+	l.mustFail(auctions.CloseAuction(l.as(l.seller, nil), "A1"), "auction A1 is already closed")
+}
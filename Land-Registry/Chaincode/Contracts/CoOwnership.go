@@ -0,0 +1,662 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CoBuyer is a person buying jointly with the buyer who makes an offer. The
+// offering buyer holds whatever share the co-buyers leave.
+type CoBuyer struct {
+	BuyerID          string `json:"buyerID"`
+	BuyerMSP         string `json:"buyerMSP"`
+	BuyerName        string `json:"buyerName"`
+	Aadhar           string `json:"aadhar"`
+	ShareBasisPoints int    `json:"shareBasisPoints"`
+}
+
+// CoOwner is one holder in a private ownership record
+type CoOwner struct {
+	OwnerID          string `json:"ownerID"`
+	Name             string `json:"name"`
+	Aadhar           string `json:"aadhar"`
+	ShareBasisPoints int    `json:"shareBasisPoints"`
+}
+
+// SaleConsent is a joint holder's agreement to the sale of a land under the
+// offer its managing owner accepted
+type SaleConsent struct {
+	LandID      string `json:"landID"`
+	OfferID     string `json:"offerID"`
+	HolderID    string `json:"holderID"`
+	ConsentedAt string `json:"consentedAt"`
+}
+
+// ShareConsent is an identity's agreement to become a holder of a land, either
+// by receiving a share from a holder or by buying jointly under an offer
+type ShareConsent struct {
+	LandID           string `json:"landID"`
+	Source           string `json:"source"` // the transferring holder, or the offer
+	HolderID         string `json:"holderID"`
+	HolderMSP        string `json:"holderMSP"`
+	ShareBasisPoints int    `json:"shareBasisPoints"`
+	ConsentedAt      string `json:"consentedAt"`
+}
+
+// Holding is one land an identity holds all or part of
+type Holding struct {
+	LandID           string `json:"landID"`
+	Status           string `json:"status"`
+	ShareBasisPoints int    `json:"shareBasisPoints"`
+	Managing         bool   `json:"managing"` // the holder is the owner of record
+}
+
+// Joint holder, or their attorney, agrees to selling the land under its accepted
+// offer; an empty holderID means the caller. The managing owner's acceptance
+// already counts as their consent.
+func (c *LandContract) ConsentToSale(ctx contractapi.TransactionContextInterface, landID string, offerID string, holderID string) error {
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if land.AcceptedOfferID == "" || land.AcceptedOfferID != offerID {
+		return fmt.Errorf("offer %s is not the accepted offer for land %s", offerID, landID)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if holderID == "" {
+		holderID = clientID
+	}
+	if holderShare(land, holderID) == 0 {
+		return fmt.Errorf("only a holder of land %s can consent to its sale", landID)
+	}
+	agent := ""
+	if holderID != clientID {
+		grant, err := actingAgent(ctx, "ConsentToSale", landID, holderID)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			return fmt.Errorf("only a holder of land %s or their attorney can consent to its sale", landID)
+		}
+		agent = grant.Agent
+	}
+
+	key, err := saleConsentKey(ctx, landID, offerID, holderID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read sale consent: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("holder has already consented to offer %s", offerID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	consent := SaleConsent{
+		LandID:      landID,
+		OfferID:     offerID,
+		HolderID:    holderID,
+		ConsentedAt: now.Format(time.RFC3339),
+	}
+	consentJSON, err := json.Marshal(consent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sale consent: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, consentJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store sale consent: %v", err)
+	}
+
+	return emitEvent(ctx, EventSaleConsented, LandEvent{LandID: landID, OfferID: offerID, Agent: agent})
+}
+
+// Anyone lists the holders' consents to the sale of a land under its accepted offer
+func (c *LandContract) GetSaleConsents(ctx contractapi.TransactionContextInterface, landID string) ([]*SaleConsent, error) {
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+	if land.AcceptedOfferID == "" {
+		return []*SaleConsent{}, nil
+	}
+	return readSaleConsents(ctx, landID, land.AcceptedOfferID)
+}
+
+// Recipient agrees to receive shareBasisPoints of a land from the holder fromID.
+// The consent is used up by the matching TransferShare.
+func (c *LandContract) AcceptShareTransfer(ctx contractapi.TransactionContextInterface, landID string, fromID string, shareBasisPoints int) error {
+	if fromID == "" {
+		return fmt.Errorf("fromID is required")
+	}
+	if shareBasisPoints <= 0 {
+		return fmt.Errorf("shareBasisPoints must be positive")
+	}
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if holderShare(land, fromID) == 0 {
+		return fmt.Errorf("%s is not a holder of land %s", fromID, landID)
+	}
+	err = consentToShare(ctx, landID, fromID, shareBasisPoints)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventShareConsented, LandEvent{LandID: landID})
+}
+
+// Co-buyer named in an offer agrees to buy shareBasisPoints of the land jointly
+// under it. The offer is private to the buyer and seller, so the consent names
+// the share and RegisterToBuyer matches it against the offer.
+func (c *LandContract) ConfirmCoBuy(ctx contractapi.TransactionContextInterface, landID string, offerID string, shareBasisPoints int) error {
+	if offerID == "" {
+		return fmt.Errorf("offerID is required")
+	}
+	if shareBasisPoints <= 0 {
+		return fmt.Errorf("shareBasisPoints must be positive")
+	}
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if land.Status != StatusForSale {
+		return fmt.Errorf("land with ID %s is not listed for sale", landID)
+	}
+	err = consentToShare(ctx, landID, offerID, shareBasisPoints)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventShareConsented, LandEvent{LandID: landID, OfferID: offerID})
+}
+
+// Holder, or their attorney, passes part or all of the holder's share of a
+// jointly held land to another identity, e.g. by gift or a private sale; an
+// empty holderID means the caller. The land must stay jointly held, so a whole
+// title only changes hands by sale. The recipient must have accepted the share
+// (see AcceptShareTransfer). The land must be off the market, and active liens
+// need their holders' consent to the "TransferShare" action.
+func (c *LandContract) TransferShare(ctx contractapi.TransactionContextInterface, landID string, toID string, toMSP string, shareBasisPoints int, holderID string) error {
+	if toID == "" || toMSP == "" {
+		return fmt.Errorf("toID and toMSP are required")
+	}
+	if shareBasisPoints <= 0 {
+		return fmt.Errorf("shareBasisPoints must be positive")
+	}
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = config.requireMSP(toMSP, PartySeller, PartyBuyer)
+	if err != nil {
+		return err
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if len(land.Shares) == 0 {
+		return fmt.Errorf("land with ID %s is not jointly held; a sole owner sells it through an offer", landID)
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	fromID := holderID
+	if fromID == "" {
+		fromID = clientID
+	}
+	if fromID == toID {
+		return fmt.Errorf("a holder cannot transfer a share to themselves")
+	}
+	held := holderShare(land, fromID)
+	if held == 0 {
+		return fmt.Errorf("only a holder of land %s can transfer a share of it", landID)
+	}
+	if shareBasisPoints > held {
+		return fmt.Errorf("holder has %d basis points of land %s, fewer than %d", held, landID, shareBasisPoints)
+	}
+	agent := ""
+	if fromID != clientID {
+		grant, err := actingAgent(ctx, "TransferShare", landID, fromID)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			return fmt.Errorf("only a holder of land %s or their attorney can transfer a share of it", landID)
+		}
+		agent = grant.Agent
+	}
+
+	err = requireOffMarket(land)
+	if err != nil {
+		return err
+	}
+	err = requireNotFrozen(ctx, landID)
+	if err != nil {
+		return err
+	}
+	err = requireLienConsent(ctx, landID, "TransferShare", "")
+	if err != nil {
+		return err
+	}
+
+	shares := []OwnerShare{}
+	received := false
+	for _, share := range holdings(land) {
+		switch share.OwnerID {
+		case fromID:
+			share.ShareBasisPoints -= shareBasisPoints
+			if share.ShareBasisPoints == 0 && holderShare(land, toID) == 0 {
+				// The recipient steps into the holder's place
+				shares = append(shares, OwnerShare{OwnerID: toID, OwnerMSP: toMSP, ShareBasisPoints: shareBasisPoints})
+				received = true
+				continue
+			}
+		case toID:
+			share.ShareBasisPoints += shareBasisPoints
+			received = true
+		}
+		if share.ShareBasisPoints > 0 {
+			shares = append(shares, share)
+		}
+	}
+	if !received {
+		shares = append(shares, OwnerShare{OwnerID: toID, OwnerMSP: toMSP, ShareBasisPoints: shareBasisPoints})
+	}
+	if len(shares) < 2 {
+		return fmt.Errorf("a share transfer must leave land %s jointly held; a whole title is sold through an offer", landID)
+	}
+	err = spendShareConsent(ctx, landID, fromID, toID, toMSP, shareBasisPoints)
+	if err != nil {
+		return err
+	}
+
+	previousOwner := land.Owner
+	setHoldings(land, shares)
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+	err = setLandEndorsement(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.PreviousOwner = previousOwner
+	event.Agent = agent
+	return emitEvent(ctx, EventShareTransferred, event)
+}
+
+// Anyone lists the lands an identity holds, whole or in part; an empty ownerID
+// means the caller
+func (c *LandContract) GetHoldings(ctx contractapi.TransactionContextInterface, ownerID string) ([]*Holding, error) {
+	if ownerID == "" {
+		clientID, err := getClientID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ownerID = clientID
+	}
+
+	// Owners of record are found through landOwnerIndex; the other holders of
+	// jointly held lands through their holder keys, as CouchDB cannot index
+	// the elements of the shares list
+	query, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{"owner": ownerID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build holdings query: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holdings: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var lands []*Land
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var land Land
+		err = json.Unmarshal(queryResponse.Value, &land)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling land data: %v", err)
+		}
+		lands = append(lands, &land)
+	}
+
+	holderIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("landholder", []string{ownerID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holder keys: %v", err)
+	}
+	defer holderIterator.Close()
+
+	for holderIterator.HasNext() {
+		queryResponse, err := holderIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split holder key: %v", err)
+		}
+		land, err := readLand(ctx, attributes[1])
+		if err != nil {
+			return nil, err
+		}
+		if land.Owner != ownerID {
+			lands = append(lands, land)
+		}
+	}
+
+	result := []*Holding{}
+	for _, land := range lands {
+		if land.Status == StatusRetired {
+			continue
+		}
+		result = append(result, &Holding{
+			LandID:           land.LandID,
+			Status:           land.Status,
+			ShareBasisPoints: holderShare(land, ownerID),
+			Managing:         land.Owner == ownerID,
+		})
+	}
+
+	return result, nil
+}
+
+// indexHolders keeps a holder key for each holder of a jointly held land in
+// step with its shares, dropping the keys of holders the land no longer has
+func indexHolders(ctx contractapi.TransactionContextInterface, land *Land) error {
+	previousJSON, err := ctx.GetStub().GetState(land.LandID)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	var previous Land
+	if previousJSON != nil {
+		err = json.Unmarshal(previousJSON, &previous)
+		if err != nil {
+			return fmt.Errorf("error unmarshaling land data: %v", err)
+		}
+	}
+
+	for _, share := range previous.Shares {
+		if len(land.Shares) > 0 && holderShare(land, share.OwnerID) > 0 {
+			continue
+		}
+		key, err := landHolderKey(ctx, share.OwnerID, land.LandID)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete holder key: %v", err)
+		}
+	}
+	for _, share := range land.Shares {
+		if len(previous.Shares) > 0 && holderShare(&previous, share.OwnerID) > 0 {
+			continue
+		}
+		key, err := landHolderKey(ctx, share.OwnerID, land.LandID)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().PutState(key, []byte(land.LandID))
+		if err != nil {
+			return fmt.Errorf("failed to write holder key: %v", err)
+		}
+	}
+	return nil
+}
+
+// requireSaleConsent checks that holders of at least the configured share of a
+// jointly held land have consented to its accepted offer
+func requireSaleConsent(ctx contractapi.TransactionContextInterface, land *Land) error {
+	if len(land.Shares) == 0 {
+		return nil
+	}
+
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	consents, err := readSaleConsents(ctx, land.LandID, land.AcceptedOfferID)
+	if err != nil {
+		return err
+	}
+
+	consented := map[string]bool{land.Owner: true}
+	for _, consent := range consents {
+		consented[consent.HolderID] = true
+	}
+	total := 0
+	for _, share := range land.Shares {
+		if consented[share.OwnerID] {
+			total += share.ShareBasisPoints
+		}
+	}
+
+	required := config.saleConsentThreshold()
+	if total < required {
+		return fmt.Errorf("holders of %d basis points of land %s have consented to the sale, %d required", total, land.LandID, required)
+	}
+	return nil
+}
+
+// requireCoBuyerConsent checks that every co-buyer of an offer has confirmed
+// buying their share under it
+func requireCoBuyerConsent(ctx contractapi.TransactionContextInterface, offer *Offer) error {
+	for _, coBuyer := range offer.CoBuyers {
+		consent, err := readShareConsent(ctx, offer.LandID, offer.OfferID, coBuyer.BuyerID)
+		if err != nil {
+			return err
+		}
+		if consent == nil || consent.HolderMSP != coBuyer.BuyerMSP || consent.ShareBasisPoints != coBuyer.ShareBasisPoints {
+			return fmt.Errorf("co-buyer %s has not consented to buying %d basis points under offer %s", coBuyer.BuyerID, coBuyer.ShareBasisPoints, offer.OfferID)
+		}
+	}
+	return nil
+}
+
+// spendShareConsent checks the recipient of a share transfer has accepted it and
+// removes the consent, so it cannot be used again
+func spendShareConsent(ctx contractapi.TransactionContextInterface, landID string, fromID string, toID string, toMSP string, shareBasisPoints int) error {
+	consent, err := readShareConsent(ctx, landID, fromID, toID)
+	if err != nil {
+		return err
+	}
+	if consent == nil || consent.HolderMSP != toMSP || consent.ShareBasisPoints != shareBasisPoints {
+		return fmt.Errorf("recipient %s has not consented to receiving %d basis points of land %s", toID, shareBasisPoints, landID)
+	}
+	key, err := shareConsentKey(ctx, landID, fromID, toID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return fmt.Errorf("failed to remove share consent: %v", err)
+	}
+	return nil
+}
+
+// consentToShare records the caller's agreement to take shareBasisPoints of a
+// land from source, replacing any earlier consent to the same source
+func consentToShare(ctx contractapi.TransactionContextInterface, landID string, source string, shareBasisPoints int) error {
+	holderID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	holderMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if holderID == source {
+		return fmt.Errorf("a holder cannot transfer a share to themselves")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	consent := ShareConsent{
+		LandID:           landID,
+		Source:           source,
+		HolderID:         holderID,
+		HolderMSP:        holderMSP,
+		ShareBasisPoints: shareBasisPoints,
+		ConsentedAt:      now.Format(time.RFC3339),
+	}
+	consentJSON, err := json.Marshal(consent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal share consent: %v", err)
+	}
+	key, err := shareConsentKey(ctx, landID, source, holderID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, consentJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store share consent: %v", err)
+	}
+	return nil
+}
+
+// readShareConsent returns an identity's consent to take a share of a land from
+// source, or nil if they have not given one
+func readShareConsent(ctx contractapi.TransactionContextInterface, landID string, source string, holderID string) (*ShareConsent, error) {
+	key, err := shareConsentKey(ctx, landID, source, holderID)
+	if err != nil {
+		return nil, err
+	}
+	consentBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read share consent: %v", err)
+	}
+	if consentBytes == nil {
+		return nil, nil
+	}
+	var consent ShareConsent
+	err = json.Unmarshal(consentBytes, &consent)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling share consent: %v", err)
+	}
+	return &consent, nil
+}
+
+// offerShares lists the holders an offer would give the land to, the offering
+// buyer first with the share the co-buyers leave
+func offerShares(offer *Offer) []OwnerShare {
+	shares := []OwnerShare{{OwnerID: offer.BuyerID, OwnerMSP: offer.BuyerMSP, ShareBasisPoints: fullShare}}
+	for _, coBuyer := range offer.CoBuyers {
+		shares[0].ShareBasisPoints -= coBuyer.ShareBasisPoints
+		shares = append(shares, OwnerShare{OwnerID: coBuyer.BuyerID, OwnerMSP: coBuyer.BuyerMSP, ShareBasisPoints: coBuyer.ShareBasisPoints})
+	}
+	return shares
+}
+
+// holdings lists who holds a land; a sole owner holds all of it
+func holdings(land *Land) []OwnerShare {
+	if len(land.Shares) > 0 {
+		return land.Shares
+	}
+	return []OwnerShare{{OwnerID: land.Owner, OwnerMSP: land.OwnerMSP, ShareBasisPoints: fullShare}}
+}
+
+// setHoldings records who holds a land. The first holder is the owner of record,
+// and a sole holder needs no share list.
+func setHoldings(land *Land, shares []OwnerShare) {
+	land.Owner = shares[0].OwnerID
+	land.OwnerMSP = shares[0].OwnerMSP
+	land.Shares = nil
+	if len(shares) > 1 {
+		land.Shares = shares
+	}
+}
+
+// holderShare is an identity's share of a land in basis points, 0 if none
+func holderShare(land *Land, ownerID string) int {
+	for _, share := range holdings(land) {
+		if share.OwnerID == ownerID {
+			return share.ShareBasisPoints
+		}
+	}
+	return 0
+}
+
+// saleConsentThreshold is the share, in basis points, whose holders must consent
+// to a sale
+func (config *OrgConfig) saleConsentThreshold() int {
+	if config.SaleConsentBasisPoints == 0 {
+		return fullShare
+	}
+	return config.SaleConsentBasisPoints
+}
+
+// readSaleConsents returns the consents given to one offer on a land
+func readSaleConsents(ctx contractapi.TransactionContextInterface, landID string, offerID string) ([]*SaleConsent, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("saleconsent", []string{landID, offerID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sale consents: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	consents := []*SaleConsent{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var consent SaleConsent
+		err = json.Unmarshal(queryResponse.Value, &consent)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling sale consent: %v", err)
+		}
+		consents = append(consents, &consent)
+	}
+	return consents, nil
+}
+
+func shareConsentKey(ctx contractapi.TransactionContextInterface, landID string, source string, holderID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("shareconsent", []string{landID, source, holderID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create share consent key: %v", err)
+	}
+	return key, nil
+}
+
+func saleConsentKey(ctx contractapi.TransactionContextInterface, landID string, offerID string, holderID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("saleconsent", []string{landID, offerID, holderID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create sale consent key: %v", err)
+	}
+	return key, nil
+}
+
+// landHolderKey leads with the holder so their lands share a key prefix
+func landHolderKey(ctx contractapi.TransactionContextInterface, ownerID string, landID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("landholder", []string{ownerID, landID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create holder key: %v", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"reflect"
+	"testing"
+)
+
+// jointLand puts a delisted land held by the seller and the buyer
+func (l *testLedger) jointLand(landID string, sellerShare int) {
+	l.t.Helper()
+	land := &Land{LandID: landID, Location: "Village Road", Type: "Agricultural", Status: StatusDelisted}
+	setHoldings(land, []OwnerShare{
+		{OwnerID: "seller", OwnerMSP: "Org1MSP", ShareBasisPoints: sellerShare},
+		{OwnerID: "buyer", OwnerMSP: "Org2MSP", ShareBasisPoints: fullShare - sellerShare},
+	})
+	l.putLand(land)
+}
+
+func TestTransferShareNeedsRecipientConsent(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.jointLand("L1", 6000)
+
+	l.mustFail(contract.TransferShare(l.as(l.seller, nil), "L1", "cobuyer", "Org2MSP", 2000, ""), "has not consented to receiving 2000")
+	l.mustFail(contract.AcceptShareTransfer(l.as(l.coBuyer, nil), "L1", "lender", 2000), "lender is not a holder")
+	l.must(contract.AcceptShareTransfer(l.as(l.coBuyer, nil), "L1", "seller", 2000))
+
+	l.mustFail(contract.TransferShare(l.as(l.seller, nil), "L1", "cobuyer", "Org3MSP", 2000, ""), "is not an org of the seller or buyer party")
+	l.mustFail(contract.TransferShare(l.as(l.seller, nil), "L1", "cobuyer", "Org2MSP", 3000, ""), "has not consented to receiving 3000")
+	l.mustFail(contract.TransferShare(l.as(l.buyer, nil), "L1", "cobuyer", "Org2MSP", 2000, ""), "has not consented to receiving 2000")
+	l.must(contract.TransferShare(l.as(l.seller, nil), "L1", "cobuyer", "Org2MSP", 2000, ""))
+
+	want := []OwnerShare{
+		{OwnerID: "seller", OwnerMSP: "Org1MSP", ShareBasisPoints: 4000},
+		{OwnerID: "buyer", OwnerMSP: "Org2MSP", ShareBasisPoints: 4000},
+		{OwnerID: "cobuyer", OwnerMSP: "Org2MSP", ShareBasisPoints: 2000},
+	}
+	if got := l.land("L1").Shares; !reflect.DeepEqual(got, want) {
+		t.Fatalf("shares are %+v, want %+v", got, want)
+	}
+	l.mustFail(contract.TransferShare(l.as(l.seller, nil), "L1", "cobuyer", "Org2MSP", 2000, ""), "has not consented to receiving 2000")
+}
+
+func TestTransferShareKeepsLandJointlyHeld(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+	l.must(contract.AcceptShareTransfer(l.as(l.buyer, nil), "L1", "seller", 5000))
+	l.mustFail(contract.TransferShare(l.as(l.seller, nil), "L1", "buyer", "Org2MSP", 5000, ""), "is not jointly held")
+
+	l.jointLand("L2", 5000)
+	l.must(contract.AcceptShareTransfer(l.as(l.buyer, nil), "L2", "seller", 5000))
+	l.mustFail(contract.TransferShare(l.as(l.seller, nil), "L2", "buyer", "Org2MSP", 5000, ""), "must leave land L2 jointly held")
+
+	// A holder may step out entirely if someone new steps in
+	l.must(contract.AcceptShareTransfer(l.as(l.coBuyer, nil), "L2", "seller", 5000))
+	l.must(contract.TransferShare(l.as(l.seller, nil), "L2", "cobuyer", "Org2MSP", 5000, ""))
+	if land := l.land("L2"); land.Owner != "cobuyer" || holderShare(land, "seller") != 0 {
+		t.Fatalf("land owned by %s with seller share %d", land.Owner, holderShare(land, "seller"))
+	}
+}
+
+func TestCoBuyNeedsCoBuyerConfirmation(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	land := l.land("L1")
+	land.TitleVerified = true
+	l.putLand(land)
+
+	outsider := []CoBuyer{{BuyerID: "registrar", BuyerMSP: "Org3MSP", BuyerName: "Registrar", Aadhar: "999988887777", ShareBasisPoints: 3000}}
+	l.mustFail(contract.RequestToBuy(l.as(l.buyer, l.buyerRequest(l.buyer, "L1", outsider)), "O0"), "is not an org of the seller or buyer party")
+
+	coBuyers := []CoBuyer{{BuyerID: "cobuyer", BuyerMSP: "Org2MSP", BuyerName: "Co-buyer", Aadhar: "555566667777", ShareBasisPoints: 3000}}
+	l.requestToBuy(l.buyer, "O1", "L1", coBuyers)
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"))
+
+	register := func() error {
+		transient := map[string][]byte{"acceptedOffer": l.privateJSON(collectionBuyerSeller, "O1"), "documentHash": []byte("deed")}
+		_, err := contract.RegisterToBuyer(l.as(l.registrar, transient), "L1")
+		return err
+	}
+	l.mustFail(register(), "co-buyer cobuyer has not consented")
+	l.must(contract.ConfirmCoBuy(l.as(l.coBuyer, nil), "L1", "O1", 2000))
+	l.mustFail(register(), "co-buyer cobuyer has not consented")
+	l.must(contract.ConfirmCoBuy(l.as(l.coBuyer, nil), "L1", "O1", 3000))
+	l.must(register())
+
+	want := []OwnerShare{
+		{OwnerID: "buyer", OwnerMSP: "Org2MSP", ShareBasisPoints: 7000},
+		{OwnerID: "cobuyer", OwnerMSP: "Org2MSP", ShareBasisPoints: 3000},
+	}
+	if got := l.land("L1").Shares; !reflect.DeepEqual(got, want) {
+		t.Fatalf("shares are %+v, want %+v", got, want)
+	}
+	l.mustFail(contract.ConfirmCoBuy(l.as(l.coBuyer, nil), "L1", "O1", 3000), "is not listed for sale")
+}
+
+func TestGetHoldings(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.jointLand("L2", 6000)
+	l.jointLand("L3", 5000)
+	retired := l.land("L3")
+	retired.Status = StatusRetired
+	l.putLand(retired)
+
+	holdings := func(identity *testIdentity, ownerID string) []Holding {
+		t.Helper()
+		result, err := contract.GetHoldings(l.as(identity, nil), ownerID)
+		l.must(err)
+		var got []Holding
+		for _, holding := range result {
+			got = append(got, *holding)
+		}
+		return got
+	}
+
+	want := []Holding{
+		{LandID: "L1", Status: StatusForSale, ShareBasisPoints: fullShare, Managing: true},
+		{LandID: "L2", Status: StatusDelisted, ShareBasisPoints: 6000, Managing: true},
+	}
+	if got := holdings(l.seller, ""); !reflect.DeepEqual(got, want) {
+		t.Fatalf("seller holds %+v, want %+v", got, want)
+	}
+	want = []Holding{{LandID: "L2", Status: StatusDelisted, ShareBasisPoints: 4000}}
+	if got := holdings(l.seller, "buyer"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("buyer holds %+v, want %+v", got, want)
+	}
+
+	// A holder who steps out of a land no longer holds it
+	l.must(contract.AcceptShareTransfer(l.as(l.coBuyer, nil), "L2", "buyer", 4000))
+	l.must(contract.TransferShare(l.as(l.buyer, nil), "L2", "cobuyer", "Org2MSP", 4000, ""))
+	if got := holdings(l.buyer, ""); len(got) != 0 {
+		t.Fatalf("buyer still holds %+v", got)
+	}
+	want = []Holding{{LandID: "L2", Status: StatusDelisted, ShareBasisPoints: 4000}}
+	if got := holdings(l.buyer, "cobuyer"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("cobuyer holds %+v, want %+v", got, want)
+	}
+}
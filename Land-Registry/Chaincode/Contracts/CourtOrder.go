@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	OrderActive = "Active"
+	OrderLifted = "Lifted"
+)
+
+// CourtOrder is a stay or freeze order on a land. It stops listing, offers and
+// transfers until it is lifted or expires.
+type CourtOrder struct {
+	OrderID      string `json:"orderID"`
+	LandID       string `json:"landID"`
+	CaseRef      string `json:"caseRef"`
+	DocumentHash string `json:"documentHash"` // hash of the order document, kept off-chain
+	IssuedBy     string `json:"issuedBy"`     // client identity ID of the officer who recorded it
+	IssuerMSP    string `json:"issuerMSP"`
+	IssuedAt     string `json:"issuedAt"`
+	Expiry       string `json:"expiry"` // RFC3339
+	Status       string `json:"status"` // Active, Lifted
+	LiftedAt     string `json:"liftedAt,omitempty"`
+}
+
+// Judiciary records a court order freezing a land until expiry (RFC3339)
+func (c *LandContract) FreezeLand(ctx contractapi.TransactionContextInterface, landID string, orderID string, caseRef string, documentHash string, expiry string) error {
+	err := authorize(ctx, "FreezeLand")
+	if err != nil {
+		return err
+	}
+	if orderID == "" || caseRef == "" || documentHash == "" {
+		return fmt.Errorf("orderID, caseRef and documentHash are required")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		return fmt.Errorf("expiry must be an RFC3339 timestamp: %v", err)
+	}
+	if !expiresAt.After(now) {
+		return fmt.Errorf("order expiry %s is already in the past", expiry)
+	}
+
+	if _, err := readLand(ctx, landID); err != nil {
+		return err
+	}
+
+	key, err := courtOrderKey(ctx, landID, orderID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read court order: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("court order with ID %s already exists on land %s", orderID, landID)
+	}
+
+	issuedBy, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+
+	order := CourtOrder{
+		OrderID:      orderID,
+		LandID:       landID,
+		CaseRef:      caseRef,
+		DocumentHash: documentHash,
+		IssuedBy:     issuedBy,
+		IssuerMSP:    msp,
+		IssuedAt:     now.Format(time.RFC3339),
+		Expiry:       expiresAt.UTC().Format(time.RFC3339),
+		Status:       OrderActive,
+	}
+	err = putCourtOrder(ctx, &order)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLandFrozen, LandEvent{LandID: landID, OrderID: orderID})
+}
+
+// Judiciary lifts a court order before it expires
+func (c *LandContract) UnfreezeLand(ctx contractapi.TransactionContextInterface, landID string, orderID string) error {
+	err := authorize(ctx, "UnfreezeLand")
+	if err != nil {
+		return err
+	}
+
+	key, err := courtOrderKey(ctx, landID, orderID)
+	if err != nil {
+		return err
+	}
+	orderBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read court order: %v", err)
+	}
+	if orderBytes == nil {
+		return fmt.Errorf("court order with ID %s does not exist on land %s", orderID, landID)
+	}
+	var order CourtOrder
+	err = json.Unmarshal(orderBytes, &order)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling court order: %v", err)
+	}
+	if order.Status != OrderActive {
+		return fmt.Errorf("court order %s is already lifted", orderID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	order.Status = OrderLifted
+	order.LiftedAt = now.Format(time.RFC3339)
+	err = putCourtOrder(ctx, &order)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLandUnfrozen, LandEvent{LandID: landID, OrderID: orderID})
+}
+
+// Anyone lists the court orders in force on a land, i.e. not lifted or expired
+func (c *LandContract) GetActiveOrders(ctx contractapi.TransactionContextInterface, landID string) ([]*CourtOrder, error) {
+	if _, err := readLand(ctx, landID); err != nil {
+		return nil, err
+	}
+	return activeCourtOrders(ctx, landID)
+}
+
+// requireNotFrozen refuses to list, sell or transfer a land under a court order
+func requireNotFrozen(ctx contractapi.TransactionContextInterface, landID string) error {
+	orders, err := activeCourtOrders(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if len(orders) > 0 {
+		order := orders[0]
+		return fmt.Errorf("land with ID %s is frozen by court order %s in case %s until %s", landID, order.OrderID, order.CaseRef, order.Expiry)
+	}
+	return nil
+}
+
+// activeCourtOrders returns a land's orders that are neither lifted nor expired
+// at the transaction time
+func activeCourtOrders(ctx contractapi.TransactionContextInterface, landID string) ([]*CourtOrder, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("courtorder", []string{landID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read court orders: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	orders := []*CourtOrder{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var order CourtOrder
+		err = json.Unmarshal(queryResponse.Value, &order)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling court order: %v", err)
+		}
+		expiry, err := time.Parse(time.RFC3339, order.Expiry)
+		if err != nil {
+			return nil, fmt.Errorf("court order %s has an invalid expiry: %v", order.OrderID, err)
+		}
+		if order.Status == OrderActive && now.Before(expiry) {
+			orders = append(orders, &order)
+		}
+	}
+	return orders, nil
+}
+
+// putCourtOrder writes a court order to the public ledger
+func putCourtOrder(ctx contractapi.TransactionContextInterface, order *CourtOrder) error {
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal court order: %v", err)
+	}
+	key, err := courtOrderKey(ctx, order.LandID, order.OrderID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, orderJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store court order: %v", err)
+	}
+	return nil
+}
+
+func courtOrderKey(ctx contractapi.TransactionContextInterface, landID string, orderID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("courtorder", []string{landID, orderID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create court order key: %v", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreezeBlocksSale(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	judge := newTestIdentity(t, "judge", "Org3MSP", RoleJudge)
+	l.listLand("L1")
+	land := l.land("L1")
+	land.TitleVerified = true
+	l.putLand(land)
+	l.requestToBuy(l.buyer, "O1", "L1", nil)
+	expiry := l.now.Add(30 * 24 * time.Hour).Format(time.RFC3339)
+
+	l.mustFail(contract.FreezeLand(l.as(l.registrar, nil), "L1", "C1", "case-1", "order", expiry), "the Judiciary can freeze land")
+	l.mustFail(contract.FreezeLand(l.as(l.seller, nil), "L1", "C1", "case-1", "order", expiry), "the Judiciary can freeze land")
+	l.must(contract.FreezeLand(l.as(judge, nil), "L1", "C1", "case-1", "order", expiry))
+	frozen := "land with ID L1 is frozen by court order C1 in case case-1 until " + expiry
+
+	l.mustFail(contract.RequestToBuy(l.as(l.coBuyer, l.buyerRequest(l.coBuyer, "L1", nil)), "O2"), frozen)
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"), frozen)
+	orders, err := contract.GetActiveOrders(l.as(l.buyer, nil), "L1")
+	l.must(err)
+	if len(orders) != 1 || orders[0].OrderID != "C1" || orders[0].IssuedBy != "judge" {
+		t.Fatalf("unexpected active orders: %+v", orders)
+	}
+
+	l.mustFail(contract.UnfreezeLand(l.as(l.registrar, nil), "L1", "C1"), "the Judiciary can unfreeze land")
+	l.must(contract.UnfreezeLand(l.as(judge, nil), "L1", "C1"))
+	l.mustFail(contract.UnfreezeLand(l.as(judge, nil), "L1", "C1"), "court order C1 is already lifted")
+	l.must(contract.RequestToBuy(l.as(l.coBuyer, l.buyerRequest(l.coBuyer, "L1", nil)), "O2"))
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"))
+
+	l.must(contract.FreezeLand(l.as(judge, nil), "L1", "C2", "case-2", "order", expiry))
+	transient := map[string][]byte{"acceptedOffer": l.privateJSON(collectionBuyerSeller, "O1"), "documentHash": []byte("deed")}
+	_, err = contract.RegisterToBuyer(l.as(l.registrar, transient), "L1")
+	l.mustFail(err, "land with ID L1 is frozen by court order C2")
+	l.must(contract.UnfreezeLand(l.as(judge, nil), "L1", "C2"))
+	_, err = contract.RegisterToBuyer(l.as(l.registrar, transient), "L1")
+	l.must(err)
+	if land := l.land("L1"); land.Owner != "buyer" {
+		t.Fatalf("land owned by %s after the freeze was lifted, want buyer", land.Owner)
+	}
+}
+
+func TestFreezeBlocksListingUntilExpiry(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	judge := newTestIdentity(t, "judge", "Org3MSP", RoleJudge)
+	l.listLand("L1")
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+
+	l.mustFail(contract.FreezeLand(l.as(judge, nil), "L1", "C1", "case-1", "order", l.now.Format(time.RFC3339)), "is already in the past")
+	l.must(contract.FreezeLand(l.as(judge, nil), "L1", "C1", "case-1", "order", l.now.Add(time.Hour).Format(time.RFC3339)))
+	l.mustFail(contract.FreezeLand(l.as(judge, nil), "L1", "C1", "case-1", "order", l.now.Add(time.Hour).Format(time.RFC3339)), "court order with ID C1 already exists on land L1")
+	l.mustFail(l.relist("L1", nil), "land with ID L1 is frozen by court order C1")
+
+	l.advance(2 * time.Hour)
+	orders, err := contract.GetActiveOrders(l.as(l.buyer, nil), "L1")
+	l.must(err)
+	if len(orders) != 0 {
+		t.Fatalf("expired order still active: %+v", orders)
+	}
+	l.must(l.relist("L1", nil))
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// setLandEndorsement puts a key-level endorsement policy on a parcel: peers of the
+// owner's org, of any joint holder's org and of every registry org must all
+// endorse any later change to it, so no single org can rewrite the parcel's
+// public state on its own
+func setLandEndorsement(ctx contractapi.TransactionContextInterface, land *Land) error {
+	if land.OwnerMSP == "" {
+		return fmt.Errorf("land with ID %s has no owner org", land.LandID)
+	}
+
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy: %v", err)
+	}
+	orgs := []string{land.OwnerMSP}
+	for _, share := range land.Shares {
+		orgs = append(orgs, share.OwnerMSP)
+	}
+	orgs = append(orgs, config.Parties[PartyRegistry]...)
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return fmt.Errorf("failed to add orgs to endorsement policy: %v", err)
+	}
+
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy bytes: %v", err)
+	}
+	err = ctx.GetStub().SetStateValidationParameter(land.LandID, policy)
+	if err != nil {
+		return fmt.Errorf("failed to set endorsement policy for land %s: %v", land.LandID, err)
+	}
+
+	return nil
+}
+
+// Anyone lists the orgs whose peers must endorse changes to a parcel. It is empty
+// for parcels that have not been listed or transferred since key-level policies
+// were introduced; those are still covered by the chaincode endorsement policy.
+func (c *LandContract) GetLandEndorsementOrgs(ctx contractapi.TransactionContextInterface, landID string) ([]string, error) {
+	_, err := readLand(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ctx.GetStub().GetStateValidationParameter(landID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endorsement policy for land %s: %v", landID, err)
+	}
+	if policy == nil {
+		return []string{}, nil
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endorsement policy for land %s: %v", landID, err)
+	}
+	return endorsementPolicy.ListOrgs(), nil
+}
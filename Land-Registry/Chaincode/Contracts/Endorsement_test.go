@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// endorsementOrgs lists the orgs of a land's key-level endorsement policy, sorted
+func (l *testLedger) endorsementOrgs(landID string) []string {
+	l.t.Helper()
+	contract := &LandContract{}
+	orgs, err := contract.GetLandEndorsementOrgs(l.as(l.registrar, nil), landID)
+	l.must(err)
+	sort.Strings(orgs)
+	return orgs
+}
+
+func TestLandEndorsementFollowsHolders(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+
+	l.jointLand("L0", 5000)
+	if orgs := l.endorsementOrgs("L0"); len(orgs) != 0 {
+		t.Fatalf("land without a key-level policy lists orgs %v", orgs)
+	}
+
+	l.listLand("L1")
+	if orgs := l.endorsementOrgs("L1"); !reflect.DeepEqual(orgs, []string{"Org1MSP", "Org3MSP"}) {
+		t.Fatalf("listed land endorsed by %v, want the owner's org and the registry", orgs)
+	}
+
+	land := l.land("L1")
+	land.TitleVerified = true
+	l.putLand(land)
+	l.requestToBuy(l.buyer, "O1", "L1", nil)
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"))
+	_, err := contract.RegisterToBuyer(l.as(l.registrar, map[string][]byte{
+		"acceptedOffer": l.privateJSON(collectionBuyerSeller, "O1"),
+		"documentHash":  []byte("deed"),
+	}), "L1")
+	l.must(err)
+	if orgs := l.endorsementOrgs("L1"); !reflect.DeepEqual(orgs, []string{"Org2MSP", "Org3MSP"}) {
+		t.Fatalf("sold land endorsed by %v, want the buyer's org and the registry", orgs)
+	}
+
+	// A share transfer covers every joint holder's org
+	l.jointLand("L2", 5000)
+	l.must(contract.AcceptShareTransfer(l.as(l.coBuyer, nil), "L2", "buyer", 5000))
+	l.must(contract.TransferShare(l.as(l.buyer, nil), "L2", "cobuyer", "Org2MSP", 5000, ""))
+	if orgs := l.endorsementOrgs("L2"); !reflect.DeepEqual(orgs, []string{"Org1MSP", "Org2MSP", "Org3MSP"}) {
+		t.Fatalf("jointly held land endorsed by %v, want every holder's org and the registry", orgs)
+	}
+}
+
+func TestLandEndorsementIncludesEveryRegistryOrg(t *testing.T) {
+	l := newTestLedger(t)
+	admin := newTestIdentity(t, "admin", "Org3MSP", RoleAdmin)
+	contract := &LandContract{}
+
+	config := `{"parties":{"seller":["Org1MSP"],"buyer":["Org2MSP"],"registry":["Org3MSP","Org4MSP"]}}`
+	l.mustFail(contract.SetOrgConfig(l.as(l.registrar, nil), config), "only a LandRegistry admin")
+	l.must(contract.SetOrgConfig(l.as(admin, nil), config))
+
+	l.listLand("L1")
+	if orgs := l.endorsementOrgs("L1"); !reflect.DeepEqual(orgs, []string{"Org1MSP", "Org3MSP", "Org4MSP"}) {
+		t.Fatalf("land endorsed by %v, want the owner's org and both registry orgs", orgs)
+	}
+
+	l.mustFail(setLandEndorsement(l.as(l.registrar, nil), &Land{LandID: "L2"}), "has no owner org")
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// EventSchemaVersion is bumped whenever a field is removed or changes meaning.
+// Adding optional fields does not change the version.
+const EventSchemaVersion = 1
+
+// Chaincode event names. These are a stable contract for off-chain integrations.
+const (
+	EventLandListed                   = "LandListed"
+	EventLandDelisted                 = "LandDelisted"
+	EventLandPriceUpdated             = "LandPriceUpdated"
+	EventOfferCreated                 = "OfferCreated"
+	EventOfferAccepted                = "OfferAccepted"
+	EventOfferRejected                = "OfferRejected"
+	EventOfferWithdrawn               = "OfferWithdrawn"
+	EventOwnershipTransferred         = "OwnershipTransferred"
+	EventSellerTitleSubmitted         = "SellerTitleSubmitted"
+	EventSellerTitleVerified          = "SellerTitleVerified"
+	EventLandRecordsMigrated          = "LandRecordsMigrated"
+	EventLegacyOwnerAssigned          = "LegacyOwnerAssigned"
+	EventOrgConfigUpdated             = "OrgConfigUpdated"
+	EventAuctionStarted               = "AuctionStarted"
+	EventBidCommitted                 = "BidCommitted"
+	EventBidRevealed                  = "BidRevealed"
+	EventAuctionClosed                = "AuctionClosed"
+	EventWinningBidRecorded           = "WinningBidRecorded"
+	EventLandSplit                    = "LandSplit"
+	EventLandsMerged                  = "LandsMerged"
+	EventParcelChangeRequested        = "ParcelChangeRequested"
+	EventParcelRequestCancelled       = "ParcelRequestCancelled"
+	EventLienApproved                 = "LienApproved"
+	EventLienRegistered               = "LienRegistered"
+	EventLienReleased                 = "LienReleased"
+	EventLienholderCertificateUpdated = "LienholderCertificateUpdated"
+	EventLandFrozen                   = "LandFrozen"
+	EventLandUnfrozen                 = "LandUnfrozen"
+	EventLeaseRegistered              = "LeaseRegistered"
+	EventLeaseAccepted                = "LeaseAccepted"
+	EventLeaseRenewed                 = "LeaseRenewed"
+	EventLeaseTerminated              = "LeaseTerminated"
+	EventSuccessionInitiated          = "SuccessionInitiated"
+	EventSuccessionObjected           = "SuccessionObjected"
+	EventObjectionResolved            = "ObjectionResolved"
+	EventSuccessionCancelled          = "SuccessionCancelled"
+	EventSuccessionFinalized          = "SuccessionFinalized"
+	EventSaleConsented                = "SaleConsented"
+	EventShareConsented               = "ShareConsented"
+	EventShareTransferred             = "ShareTransferred"
+	EventPowerOfAttorneyGranted       = "PowerOfAttorneyGranted"
+	EventPowerOfAttorneyRevoked       = "PowerOfAttorneyRevoked"
+)
+
+// LandEvent is the payload of every chaincode event. Events are visible to every
+// channel member, so only public ledger fields go here: never offer or bid prices,
+// buyer names, Aadhar numbers or title documents.
+type LandEvent struct {
+	Version   int    `json:"version"`
+	Type      string `json:"type"`
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"` // RFC3339, transaction time
+
+	LandID        string       `json:"landID,omitempty"`
+	Status        string       `json:"status,omitempty"`
+	Owner         string       `json:"owner,omitempty"`
+	PreviousOwner string       `json:"previousOwner,omitempty"`
+	PriceMinor    int64        `json:"priceMinor,omitempty"` // public asking price only
+	OfferID       string       `json:"offerID,omitempty"`
+	AuctionID     string       `json:"auctionID,omitempty"`
+	BidID         string       `json:"bidID,omitempty"`
+	LienID        string       `json:"lienID,omitempty"`
+	OrderID       string       `json:"orderID,omitempty"`
+	RequestID     string       `json:"requestID,omitempty"` // split or merge request
+	LeaseID       string       `json:"leaseID,omitempty"`
+	LeasedUntil   string       `json:"leasedUntil,omitempty"`
+	SuccessionID  string       `json:"successionID,omitempty"`
+	ObjectionID   string       `json:"objectionID,omitempty"`
+	GrantID       string       `json:"grantID,omitempty"`
+	Agent         string       `json:"agent,omitempty"` // attorney who acted for the owner, holder or buyer
+	LandIDs       []string     `json:"landIDs,omitempty"`
+	Shares        []OwnerShare `json:"shares,omitempty"`
+}
+
+// emitEvent stamps and sets the transaction's chaincode event. Fabric keeps a
+// single event per transaction, so each transaction emits exactly one.
+func emitEvent(ctx contractapi.TransactionContextInterface, eventType string, event LandEvent) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	event.Version = EventSchemaVersion
+	event.Type = eventType
+	event.TxID = ctx.GetStub().GetTxID()
+	event.Timestamp = now.Format(time.RFC3339)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %v", eventType, err)
+	}
+
+	err = ctx.GetStub().SetEvent(eventType, payload)
+	if err != nil {
+		return fmt.Errorf("failed to set %s event: %v", eventType, err)
+	}
+
+	return nil
+}
+
+// landEvent fills the public land fields shared by most events
+func landEvent(land *Land) LandEvent {
+	return LandEvent{
+		LandID:      land.LandID,
+		Status:      land.Status,
+		Owner:       land.Owner,
+		PriceMinor:  land.PriceMinor,
+		LeasedUntil: land.LeasedUntil,
+		Shares:      land.Shares,
+	}
+}
@@ -4,6 +4,7 @@ package contracts
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -13,38 +14,75 @@ type LandContract struct {
 }
 
 type Land struct {
-	LandID       string `json:"landID"`
-	Location     string `json:"location"`
-	Size         string `json:"size"`
-	Type         string `json:"type"`
-	SoilQuality  string `json:"soilQuality"`
-	WaterSource  string `json:"waterSource"`
-	NearbyRoad   string `json:"nearbyRoad"`
-	NearbyCity   string `json:"nearbyCity"`
-	Coordinates  string `json:"coordinates"`
-	SellingPrice string `json:"sellingPrice"`
-	Status       string `json:"status"` // For Sale, Sold
+	LandID      string  `json:"landID"`
+	Location    string  `json:"location"`
+	Area        float64 `json:"area"`     // as entered, in AreaUnit
+	AreaUnit    string  `json:"areaUnit"` // sqft, sqm, acre, hectare, cent, guntha
+	AreaSqm     float64 `json:"areaSqm"`  // Area normalized to square metres
+	Type        string  `json:"type"`
+	SoilQuality string  `json:"soilQuality"`
+	WaterSource string  `json:"waterSource"`
+	NearbyRoad  string  `json:"nearbyRoad"`
+	NearbyCity  string  `json:"nearbyCity"`
+	Coordinates string  `json:"coordinates"`
+	PriceMinor  int64   `json:"priceMinor"` // selling price in minor currency units (paise)
+	Status      string  `json:"status"`     // For Sale, In Auction, Sold, Delisted, Retired
+	Owner       string  `json:"owner"`      // client identity ID of the current title holder
+	OwnerMSP    string  `json:"ownerMSP"`   // org of the current title holder, which must endorse changes
+
+	Shares       []OwnerShare `json:"shares,omitempty"`       // joint holders and their shares; Owner acts for them
+	SuccessionID string       `json:"successionID,omitempty"` // succession pending on the owner's death
+
+	AcceptedOfferID string `json:"acceptedOfferID,omitempty"` // offer the owner agreed to, awaiting registry
+	AcceptedExpiry  string `json:"acceptedExpiry,omitempty"`  // RFC3339 expiry of that offer
+	AuctionID       string `json:"auctionID,omitempty"`       // sealed-bid auction in progress
+	LeaseID         string `json:"leaseID,omitempty"`         // latest lease; terms are private to owner and lessee
+	LeasedUntil     string `json:"leasedUntil,omitempty"`     // RFC3339 end of that lease
+	TitleVerified   bool   `json:"titleVerified"`             // Registry has checked the seller's title
+
+	ParentIDs []string `json:"parentIDs,omitempty"` // parcels this one was split from or merged out of
+	ChildIDs  []string `json:"childIDs,omitempty"`  // parcels that replaced this one once retired
 }
 
+const (
+	StatusForSale   = "For Sale"
+	StatusSold      = "Sold"
+	StatusDelisted  = "Delisted"
+	StatusInAuction = "In Auction"
+	StatusRetired   = "Retired" // split or merged into other parcels
+)
+
 type BuyerOwnership struct {
-	OwnerID      string `json:"ownerID"`
-	BuyerName    string `json:"buyerName"`
-	Aadhar       string `json:"aadhar"`
-	DocumentHash string `json:"documentHash"`
-	TransferDate string `json:"transferDate"`
-	LandID       string `json:"landID"`
-	Location     string `json:"location"`
-	Size         string `json:"size"`
-	Type         string `json:"type"`
-	Coordinates  string `json:"coordinates"`
-	SellingPrice string `json:"sellingPrice"`
+	OwnerID      string    `json:"ownerID"`
+	BuyerName    string    `json:"buyerName"`
+	Aadhar       string    `json:"aadhar"`
+	DocumentHash string    `json:"documentHash"`
+	TransferDate string    `json:"transferDate"`
+	LandID       string    `json:"landID"`
+	Location     string    `json:"location"`
+	Size         string    `json:"size"`
+	Type         string    `json:"type"`
+	Coordinates  string    `json:"coordinates"`
+	SellingPrice string    `json:"sellingPrice"`
+	CoOwners     []CoOwner `json:"coOwners,omitempty"` // joint buyers, the first being OwnerID
 }
 
-// Org1 Seller lists land to public ledger
-func (c *LandContract) ListLand(ctx contractapi.TransactionContextInterface, landID string, location string, size string, landType string, soilQuality string, waterSource string, nearbyRoad string, nearbyCity string, coordinates string, sellingPrice string) error {
-	msp, _ := ctx.GetClientIdentity().GetMSPID()
-	if msp != "Org1MSP" {
-		return fmt.Errorf("only Seller (Org1) can list land")
+// Org1 Seller lists land to public ledger. A parcel that already exists can
+// only be re-listed by its current owner or their attorney, which is how a
+// buyer resells land, and needs the consent of any lienholder.
+// Area is given in areaUnit and the price in minor currency units (paise).
+func (c *LandContract) ListLand(ctx contractapi.TransactionContextInterface, landID string, location string, area float64, areaUnit string, landType string, soilQuality string, waterSource string, nearbyRoad string, nearbyCity string, coordinates string, priceMinor int64) error {
+	if landID == "" || location == "" || landType == "" {
+		return fmt.Errorf("landID, location and type are required")
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
 	}
 
 	existing, err := ctx.GetStub().GetState(landID)
@@ -52,63 +90,239 @@ func (c *LandContract) ListLand(ctx contractapi.TransactionContextInterface, lan
 		return fmt.Errorf("failed to read land from world state: %v", err)
 	}
 	if existing != nil {
-		return fmt.Errorf("land with ID %s already exists", landID)
+		err = authorize(ctx, "ManageLand")
+		if err != nil {
+			return err
+		}
+		var land Land
+		err = json.Unmarshal(existing, &land)
+		if err != nil {
+			return fmt.Errorf("error unmarshaling land data: %v", err)
+		}
+		agent := ""
+		if land.Owner != clientID {
+			grant, err := actingAgent(ctx, "ListLand", landID, land.Owner)
+			if err != nil {
+				return err
+			}
+			if grant == nil {
+				return fmt.Errorf("land with ID %s already exists", landID)
+			}
+			agent = grant.Agent
+			msp = grant.PrincipalMSP
+		}
+		if land.Status == StatusForSale || land.Status == StatusInAuction {
+			return fmt.Errorf("land with ID %s is already listed for sale", landID)
+		}
+		if land.Status == StatusRetired {
+			return fmt.Errorf("land with ID %s has been retired", landID)
+		}
+		if land.SuccessionID != "" {
+			return fmt.Errorf("land with ID %s has succession %s pending", landID, land.SuccessionID)
+		}
+		err = requireNotFrozen(ctx, landID)
+		if err != nil {
+			return err
+		}
+		err = requireLienConsent(ctx, landID, "ListLand", "")
+		if err != nil {
+			return err
+		}
+
+		err = setLandArea(&land, area, areaUnit)
+		if err != nil {
+			return err
+		}
+		err = setLandPrice(&land, priceMinor)
+		if err != nil {
+			return err
+		}
+
+		land.Location = location
+		land.Type = landType
+		land.SoilQuality = soilQuality
+		land.WaterSource = waterSource
+		land.NearbyRoad = nearbyRoad
+		land.NearbyCity = nearbyCity
+		land.Coordinates = coordinates
+		land.Status = StatusForSale
+		land.AcceptedOfferID = ""
+		land.AcceptedExpiry = ""
+		land.OwnerMSP = msp
+
+		err = putLand(ctx, &land)
+		if err != nil {
+			return err
+		}
+		err = setLandEndorsement(ctx, &land)
+		if err != nil {
+			return err
+		}
+
+		event := landEvent(&land)
+		event.Agent = agent
+		return emitEvent(ctx, EventLandListed, event)
+	}
+
+	err = authorize(ctx, "ListLand")
+	if err != nil {
+		return err
 	}
 
 	land := Land{
-		LandID:       landID,
-		Location:     location,
-		Size:         size,
-		Type:         landType,
-		SoilQuality:  soilQuality,
-		WaterSource:  waterSource,
-		NearbyRoad:   nearbyRoad,
-		NearbyCity:   nearbyCity,
-		Coordinates:  coordinates,
-		SellingPrice: sellingPrice,
-		Status:       "For Sale",
+		LandID:      landID,
+		Location:    location,
+		Type:        landType,
+		SoilQuality: soilQuality,
+		WaterSource: waterSource,
+		NearbyRoad:  nearbyRoad,
+		NearbyCity:  nearbyCity,
+		Coordinates: coordinates,
+		Status:      StatusForSale,
+		Owner:       clientID,
+		OwnerMSP:    msp,
 	}
 
-	landJSON, err := json.Marshal(land)
+	err = setLandArea(&land, area, areaUnit)
 	if err != nil {
-		return fmt.Errorf("failed to marshal land: %v", err)
+		return err
+	}
+	err = setLandPrice(&land, priceMinor)
+	if err != nil {
+		return err
 	}
 
-	err = ctx.GetStub().PutState(landID, landJSON)
+	err = putLand(ctx, &land)
 	if err != nil {
-		return fmt.Errorf("failed to write land to public ledger: %v", err)
+		return err
+	}
+	err = setLandEndorsement(ctx, &land)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return emitEvent(ctx, EventLandListed, landEvent(&land))
+}
+
+// Current owner, or their attorney, takes the land off the market
+func (c *LandContract) DelistLand(ctx contractapi.TransactionContextInterface, landID string) error {
+	err := authorize(ctx, "ManageLand")
+	if err != nil {
+		return err
+	}
+	land, agent, err := readManagedLand(ctx, "DelistLand", landID)
+	if err != nil {
+		return err
+	}
+	if land.Status != StatusForSale {
+		return fmt.Errorf("land with ID %s is not listed for sale", landID)
+	}
+
+	land.Status = StatusDelisted
+	land.AcceptedOfferID = ""
+	land.AcceptedExpiry = ""
+
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.Agent = agent
+	return emitEvent(ctx, EventLandDelisted, event)
+}
+
+// Current owner, or their attorney, changes the asking price (in minor currency
+// units) of a listed land
+func (c *LandContract) UpdateSellingPrice(ctx contractapi.TransactionContextInterface, landID string, priceMinor int64) error {
+	err := authorize(ctx, "ManageLand")
+	if err != nil {
+		return err
+	}
+	land, agent, err := readManagedLand(ctx, "UpdateSellingPrice", landID)
+	if err != nil {
+		return err
+	}
+	if land.Status != StatusForSale {
+		return fmt.Errorf("land with ID %s is not listed for sale", landID)
+	}
+
+	err = setLandPrice(land, priceMinor)
+	if err != nil {
+		return err
+	}
+
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.Agent = agent
+	return emitEvent(ctx, EventLandPriceUpdated, event)
 }
 
 // Anyone (e.g., Org1, Org2, Org3) can get public land info
 func (c *LandContract) GetLandByID(ctx contractapi.TransactionContextInterface, landID string) (*Land, error) {
-	landBytes, err := ctx.GetStub().GetState(landID)
+	return readLand(ctx, landID)
+}
+
+// LandHistory is one committed version of a land record
+type LandHistory struct {
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Land      *Land  `json:"land,omitempty"`
+}
+
+// Anyone can audit every committed version of a land, newest first
+func (c *LandContract) GetLandHistory(ctx contractapi.TransactionContextInterface, landID string) ([]*LandHistory, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(landID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read land from world state: %v", err)
+		return nil, fmt.Errorf("failed to read land history: %v", err)
 	}
-	if landBytes == nil {
-		return nil, fmt.Errorf("land with ID %s does not exist", landID)
+	defer resultsIterator.Close()
+
+	var history []*LandHistory
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := LandHistory{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if modification.Timestamp != nil {
+			entry.Timestamp = modification.Timestamp.AsTime().UTC().Format(time.RFC3339Nano)
+		}
+		if !modification.IsDelete {
+			var land Land
+			err = json.Unmarshal(modification.Value, &land)
+			if err != nil {
+				return nil, fmt.Errorf("error unmarshaling land history: %v", err)
+			}
+			entry.Land = &land
+		}
+		history = append(history, &entry)
 	}
 
-	var land Land
-	err = json.Unmarshal(landBytes, &land)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling land data: %v", err)
+	if history == nil {
+		return nil, fmt.Errorf("land with ID %s has no history", landID)
 	}
 
-	return &land, nil
+	return history, nil
 }
 
 // Buyer (Org2) views lands that are For Sale
 func (c *LandContract) GetAvailableLands(ctx contractapi.TransactionContextInterface) ([]*Land, error) {
-	msp, _ := ctx.GetClientIdentity().GetMSPID()
-	if msp != "Org2MSP" {
-		return nil, fmt.Errorf("only Buyer (Org2) can view available lands")
+	err := authorize(ctx, "GetAvailableLands")
+	if err != nil {
+		return nil, err
 	}
 
-	query := `{"selector":{"status":"For Sale"}}`
+	query := fmt.Sprintf(`{"selector":{"status":"%s"}}`, StatusForSale)
 	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query available lands: %v", err)
@@ -132,78 +346,129 @@ func (c *LandContract) GetAvailableLands(ctx contractapi.TransactionContextInter
 	return lands, nil
 }
 
-// Buyer (Org2) sends private request to buy land
-func (c *LandContract) RequestToBuy(ctx contractapi.TransactionContextInterface, offerID string) error {
-	msp, _ := ctx.GetClientIdentity().GetMSPID()
-	if msp != "Org2MSP" {
-		return fmt.Errorf("only Buyer (Org2) can send requests")
+// Land Registry (Org3) assigns land to buyer and stores private ownership.
+// The sale must rest on the offer the owner accepted: the caller presents that
+// offer in transient data and it is checked against the hash the peers hold for
+// collectionBuyerSeller, which Org3 cannot read directly. The ownership record
+// is then built from the ledger's Land and the verified offer. Active liens need
+// their holders' consents to this offer, passed as "lienConsents".
+func (c *LandContract) RegisterToBuyer(ctx contractapi.TransactionContextInterface, landID string) (string, error) {
+	err := authorize(ctx, "RegisterToBuyer")
+	if err != nil {
+		return "", err
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return "", err
+	}
+	if land.Status != StatusForSale {
+		return "", fmt.Errorf("land with ID %s is not listed for sale", landID)
+	}
+	if !land.TitleVerified {
+		return "", fmt.Errorf("seller title for land %s has not been verified", landID)
+	}
+	if land.AcceptedOfferID == "" {
+		return "", fmt.Errorf("land with ID %s has no accepted offer", landID)
+	}
+	err = requireNotFrozen(ctx, landID)
+	if err != nil {
+		return "", err
 	}
 
 	transient, err := ctx.GetStub().GetTransient()
 	if err != nil {
-		return fmt.Errorf("error getting transient data: %v", err)
+		return "", fmt.Errorf("error getting transient data: %v", err)
 	}
-	privateData, ok := transient["buyerRequest"]
+	offerData, ok := transient["acceptedOffer"]
 	if !ok {
-		return fmt.Errorf("buyerRequest key missing in transient data")
+		return "", fmt.Errorf("acceptedOffer key missing in transient")
+	}
+	documentHash, ok := transient["documentHash"]
+	if !ok || len(documentHash) == 0 {
+		return "", fmt.Errorf("documentHash key missing in transient")
 	}
 
-	err = ctx.GetStub().PutPrivateData("collectionBuyerSeller", offerID, privateData)
+	offer, err := verifyAcceptedOffer(ctx, land, offerData)
 	if err != nil {
-		return fmt.Errorf("failed to store buyer request: %v", err)
+		return "", err
 	}
-
-	return nil
-}
-
-// Land Registry (Org3) assigns land to buyer and stores private ownership
-func (c *LandContract) RegisterToBuyer(ctx contractapi.TransactionContextInterface, landID string) (string, error) {
-	msp, _ := ctx.GetClientIdentity().GetMSPID()
-	if msp != "Org3MSP" {
-		return "", fmt.Errorf("only LandRegistry (Org3) can register land to buyer")
+	// Liens stay on the land after the transfer; the lienholder agrees to the sale
+	err = requireLienConsent(ctx, landID, "RegisterToBuyer", offer.OfferID)
+	if err != nil {
+		return "", err
 	}
-
-	landBytes, err := ctx.GetStub().GetState(landID)
-	if err != nil || landBytes == nil {
-		return "", fmt.Errorf("landID not found or error: %v", err)
+	err = requireSaleConsent(ctx, land)
+	if err != nil {
+		return "", err
+	}
+	err = requireCoBuyerConsent(ctx, offer)
+	if err != nil {
+		return "", err
 	}
 
-	var land Land
-	err = json.Unmarshal(landBytes, &land)
+	now, err := txTime(ctx)
 	if err != nil {
-		return "", fmt.Errorf("error parsing land data: %v", err)
+		return "", err
 	}
 
-	land.Status = "Sold"
+	cert := BuyerOwnership{
+		OwnerID:      offer.BuyerID,
+		BuyerName:    offer.BuyerName,
+		Aadhar:       offer.Aadhar,
+		DocumentHash: string(documentHash),
+		TransferDate: now.Format(time.RFC3339),
+		LandID:       land.LandID,
+		Location:     land.Location,
+		Size:         formatArea(land),
+		Type:         land.Type,
+		Coordinates:  land.Coordinates,
+		SellingPrice: formatPrice(offer.PriceMinor),
+	}
+	if len(offer.CoBuyers) > 0 {
+		shares := offerShares(offer)
+		cert.CoOwners = []CoOwner{{OwnerID: offer.BuyerID, Name: offer.BuyerName, Aadhar: offer.Aadhar, ShareBasisPoints: shares[0].ShareBasisPoints}}
+		for _, coBuyer := range offer.CoBuyers {
+			cert.CoOwners = append(cert.CoOwners, CoOwner{OwnerID: coBuyer.BuyerID, Name: coBuyer.BuyerName, Aadhar: coBuyer.Aadhar, ShareBasisPoints: coBuyer.ShareBasisPoints})
+		}
+	}
+
+	previousOwner := land.Owner
+
+	// Status and title change together in a single write
+	land.Status = StatusSold
+	setHoldings(land, offerShares(offer))
+	land.AcceptedOfferID = ""
+	land.AcceptedExpiry = ""
+	// The registry has just issued this title, so a resale needs no separate check
+	land.TitleVerified = true
 
-	updatedJSON, err := json.Marshal(land)
+	err = putLand(ctx, land)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal updated land: %v", err)
+		return "", err
 	}
-
-	err = ctx.GetStub().PutState(landID, updatedJSON)
+	// The new owner's org takes the old one's place in the parcel's endorsement policy
+	err = setLandEndorsement(ctx, land)
 	if err != nil {
-		return "", fmt.Errorf("failed to update land status: %v", err)
+		return "", err
 	}
 
-	transient, err := ctx.GetStub().GetTransient()
+	certJSON, err := json.Marshal(cert)
 	if err != nil {
-		return "", fmt.Errorf("error getting transient data: %v", err)
-	}
-	privateOwnerData, ok := transient["buyerOwnership"]
-	if !ok {
-		return "", fmt.Errorf("buyerOwnership key missing in transient")
+		return "", fmt.Errorf("failed to marshal ownership certificate: %v", err)
 	}
 
-	err = ctx.GetStub().PutPrivateData("collectionBuyerLandRegistry", landID, privateOwnerData)
+	err = ctx.GetStub().PutPrivateData(collectionBuyerLandRegistry, landID, certJSON)
 	if err != nil {
 		return "", fmt.Errorf("failed to store private ownership data: %v", err)
 	}
 
-	var cert BuyerOwnership
-	err = json.Unmarshal(privateOwnerData, &cert)
+	event := landEvent(land)
+	event.PreviousOwner = previousOwner
+	event.OfferID = offer.OfferID
+	err = emitEvent(ctx, EventOwnershipTransferred, event)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse ownership certificate: %v", err)
+		return "", err
 	}
 
 	certificate := fmt.Sprintf(`--- Ownership Certificate ---
@@ -219,3 +484,69 @@ Transfer Date: %s
 
 	return certificate, nil
 }
+
+// readLand loads a land record from the public ledger
+func readLand(ctx contractapi.TransactionContextInterface, landID string) (*Land, error) {
+	landBytes, err := ctx.GetStub().GetState(landID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read land from world state: %v", err)
+	}
+	if landBytes == nil {
+		return nil, fmt.Errorf("land with ID %s does not exist", landID)
+	}
+
+	var land Land
+	err = json.Unmarshal(landBytes, &land)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling land data: %v", err)
+	}
+
+	return &land, nil
+}
+
+// readOwnedLand loads a land record and checks the caller is its current owner
+func readOwnedLand(ctx contractapi.TransactionContextInterface, landID string) (*Land, error) {
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if land.Owner != clientID {
+		return nil, fmt.Errorf("only the current owner can modify land %s", landID)
+	}
+
+	return land, nil
+}
+
+// putLand writes a land record to the public ledger
+func putLand(ctx contractapi.TransactionContextInterface, land *Land) error {
+	err := indexHolders(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	landJSON, err := json.Marshal(land)
+	if err != nil {
+		return fmt.Errorf("failed to marshal land: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(land.LandID, landJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write land to public ledger: %v", err)
+	}
+
+	return nil
+}
+
+// getClientID returns the unique identity of the submitting client
+func getClientID(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get client identity: %v", err)
+	}
+	return clientID, nil
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetLandHistory(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	_, err := contract.GetLandHistory(l.as(l.buyer, nil), "L1")
+	l.mustFail(err, "land with ID L1 has no history")
+
+	l.listLand("L1")
+	l.must(contract.SubmitSellerTitle(l.as(l.seller, l.sellerTitle("deed-1")), "L1"))
+	l.must(contract.VerifySellerTitle(l.as(l.registrar, nil), "L1"))
+	l.requestToBuy(l.buyer, "O1", "L1", nil)
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"))
+	l.advance(time.Hour)
+	transient := map[string][]byte{"acceptedOffer": l.privateJSON(collectionBuyerSeller, "O1"), "documentHash": []byte("deed")}
+	_, err = contract.RegisterToBuyer(l.as(l.registrar, transient), "L1")
+	l.must(err)
+	soldIn := l.stub.TxID
+	l.advance(time.Hour)
+	l.must(contract.ListLand(l.as(l.buyer, nil), "L1", "Village Road", 1, UnitAcre, "Agricultural", "Loam", "Well", "NH44", "Nagpur", "21.1,79.0", 120000000))
+	l.must(contract.DelistLand(l.as(l.buyer, nil), "L1"))
+
+	history, err := contract.GetLandHistory(l.as(l.buyer, nil), "L1")
+	l.must(err)
+	var statuses, owners []string
+	for _, entry := range history {
+		if entry.IsDelete || entry.Land == nil {
+			t.Fatalf("unexpected history entry: %+v", entry)
+		}
+		statuses = append(statuses, entry.Land.Status)
+		owners = append(owners, entry.Land.Owner)
+	}
+	wantStatuses := []string{StatusDelisted, StatusForSale, StatusSold, StatusForSale, StatusForSale, StatusForSale}
+	if !reflect.DeepEqual(statuses, wantStatuses) {
+		t.Fatalf("statuses newest first are %v, want %v", statuses, wantStatuses)
+	}
+	wantOwners := []string{"buyer", "buyer", "buyer", "seller", "seller", "seller"}
+	if !reflect.DeepEqual(owners, wantOwners) {
+		t.Fatalf("owners newest first are %v, want %v", owners, wantOwners)
+	}
+
+	sale := history[2]
+	if sale.TxID != soldIn || sale.Timestamp != "2026-01-01T01:00:00Z" || sale.Land.AcceptedOfferID != "" {
+		t.Fatalf("unexpected sale entry: %+v", sale)
+	}
+	if history[1].Land.PriceMinor != 120000000 || history[3].Land.AcceptedOfferID != "O1" || !history[4].Land.TitleVerified {
+		t.Fatalf("unexpected history: %+v %+v %+v", history[1].Land, history[3].Land, history[4].Land)
+	}
+}
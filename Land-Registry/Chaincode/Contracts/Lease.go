@@ -0,0 +1,494 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const collectionOwnerLessee = "collectionOwnerLessee"
+
+const (
+	LeasePending    = "Pending"
+	LeaseActive     = "Active"
+	LeaseTerminated = "Terminated"
+	LeaseExpired    = "Expired"
+)
+
+// Lease is a tenancy on a land, kept private between owner and lessee. Title does
+// not change hands; once the lessee accepts, the land carries the lease ID and end
+// date.
+type Lease struct {
+	DocType      string `json:"docType"`
+	LeaseID      string `json:"leaseID"`
+	LandID       string `json:"landID"`
+	OwnerID      string `json:"ownerID"` // owner who granted the lease
+	LesseeID     string `json:"lesseeID"`
+	LesseeMSP    string `json:"lesseeMSP"`
+	LesseeName   string `json:"lesseeName"`
+	Start        string `json:"start"` // RFC3339
+	End          string `json:"end"`   // RFC3339, moved by each renewal
+	RentMinor    int64  `json:"rentMinor"`
+	RentPeriod   string `json:"rentPeriod"` // e.g. monthly, yearly
+	RenewalTerms string `json:"renewalTerms"`
+	MaxRenewals  int    `json:"maxRenewals"`
+	Renewals     int    `json:"renewals"`
+	DepositHash  string `json:"depositHash"` // hash of the security deposit receipt
+	Status       string `json:"status"`      // Pending, Active, Terminated, Expired
+	TerminatedAt string `json:"terminatedAt,omitempty"`
+}
+
+// Owner, or their attorney, offers a lease on the land without transferring title. The terms go in
+// transient data as "lease"; lesseeID is the lessee's client identity ID. The lease
+// stays Pending and the land unmarked until the lessee accepts it (see AcceptLease).
+func (c *LandContract) RegisterLease(ctx contractapi.TransactionContextInterface, landID string, leaseID string) error {
+	if leaseID == "" {
+		return fmt.Errorf("leaseID is required")
+	}
+
+	err := authorize(ctx, "ManageLand")
+	if err != nil {
+		return err
+	}
+	land, agent, err := readManagedLand(ctx, "RegisterLease", landID)
+	if err != nil {
+		return err
+	}
+	if land.Status == StatusRetired {
+		return fmt.Errorf("land with ID %s has been retired", land.LandID)
+	}
+	err = requireNotFrozen(ctx, land.LandID)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if leased(land, now) {
+		return fmt.Errorf("land with ID %s is already leased until %s", land.LandID, land.LeasedUntil)
+	}
+
+	existing, err := ctx.GetStub().GetPrivateDataHash(collectionOwnerLessee, leaseID)
+	if err != nil {
+		return fmt.Errorf("failed to read lease: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("lease with ID %s already exists", leaseID)
+	}
+
+	terms, err := leaseTerms(ctx)
+	if err != nil {
+		return err
+	}
+	var request struct {
+		LesseeID     string `json:"lesseeID"`
+		LesseeMSP    string `json:"lesseeMSP"`
+		LesseeName   string `json:"lesseeName"`
+		Start        string `json:"start"`
+		End          string `json:"end"`
+		RentMinor    int64  `json:"rentMinor"`
+		RentPeriod   string `json:"rentPeriod"`
+		RenewalTerms string `json:"renewalTerms"`
+		MaxRenewals  int    `json:"maxRenewals"`
+		DepositHash  string `json:"depositHash"`
+	}
+	err = json.Unmarshal(terms, &request)
+	if err != nil {
+		return fmt.Errorf("failed to parse lease: %v", err)
+	}
+	if request.LesseeID == "" || request.LesseeMSP == "" || request.DepositHash == "" {
+		return fmt.Errorf("lesseeID, lesseeMSP and depositHash are required in lease")
+	}
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = config.requireMSP(request.LesseeMSP, PartySeller, PartyBuyer)
+	if err != nil {
+		return err
+	}
+	if request.LesseeID == land.Owner {
+		return fmt.Errorf("the owner cannot lease land to themselves")
+	}
+	if request.RentMinor <= 0 {
+		return fmt.Errorf("rentMinor must be a positive amount in minor currency units")
+	}
+	if request.MaxRenewals < 0 {
+		return fmt.Errorf("maxRenewals must not be negative")
+	}
+	start, err := time.Parse(time.RFC3339, request.Start)
+	if err != nil {
+		return fmt.Errorf("start must be an RFC3339 timestamp: %v", err)
+	}
+	end, err := time.Parse(time.RFC3339, request.End)
+	if err != nil {
+		return fmt.Errorf("end must be an RFC3339 timestamp: %v", err)
+	}
+	if !end.After(start) || !end.After(now) {
+		return fmt.Errorf("lease end %s must be after its start and in the future", request.End)
+	}
+
+	lease := Lease{
+		DocType:      "lease",
+		LeaseID:      leaseID,
+		LandID:       land.LandID,
+		OwnerID:      land.Owner,
+		LesseeID:     request.LesseeID,
+		LesseeMSP:    request.LesseeMSP,
+		LesseeName:   request.LesseeName,
+		Start:        start.UTC().Format(time.RFC3339),
+		End:          end.UTC().Format(time.RFC3339),
+		RentMinor:    request.RentMinor,
+		RentPeriod:   request.RentPeriod,
+		RenewalTerms: request.RenewalTerms,
+		MaxRenewals:  request.MaxRenewals,
+		DepositHash:  request.DepositHash,
+		Status:       LeasePending,
+	}
+	err = putLease(ctx, &lease)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.LeaseID = leaseID
+	event.Agent = agent
+	return emitEvent(ctx, EventLeaseRegistered, event)
+}
+
+// Lessee accepts a pending lease, which then takes effect: the land carries its ID
+// and end date. The lease is presented in transient data as "lease". Registry peers
+// endorse the marker on the land but cannot read the terms.
+func (c *LandContract) AcceptLease(ctx contractapi.TransactionContextInterface, leaseID string) error {
+	lease, err := verifiedLease(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if clientID != lease.LesseeID {
+		return fmt.Errorf("only the lessee can accept lease %s", leaseID)
+	}
+	if lease.Status != LeasePending {
+		return fmt.Errorf("lease %s is %s", leaseID, lease.Status)
+	}
+
+	land, err := readLand(ctx, lease.LandID)
+	if err != nil {
+		return err
+	}
+	if land.Owner != lease.OwnerID {
+		return fmt.Errorf("land with ID %s has changed owner since lease %s was offered", land.LandID, leaseID)
+	}
+	if land.Status == StatusRetired {
+		return fmt.Errorf("land with ID %s has been retired", land.LandID)
+	}
+	err = requireNotFrozen(ctx, land.LandID)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if leased(land, now) {
+		return fmt.Errorf("land with ID %s is already leased until %s", land.LandID, land.LeasedUntil)
+	}
+	if leaseExpired(lease, now) {
+		return fmt.Errorf("lease %s has expired", leaseID)
+	}
+
+	lease.Status = LeaseActive
+	err = putLease(ctx, lease)
+	if err != nil {
+		return err
+	}
+
+	land.LeaseID = leaseID
+	land.LeasedUntil = lease.End
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.LeaseID = leaseID
+	return emitEvent(ctx, EventLeaseAccepted, event)
+}
+
+// Owner or lessee extends an active lease to newEnd (RFC3339), within the number
+// of renewals its terms allow. The lease is presented in transient data as
+// "lease" (see presentedLease).
+func (c *LandContract) RenewLease(ctx contractapi.TransactionContextInterface, leaseID string, newEnd string) error {
+	lease, land, err := presentedLease(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	err = requireLeaseParty(ctx, lease, land)
+	if err != nil {
+		return err
+	}
+	err = requireNotFrozen(ctx, land.LandID)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	err = requireLeaseActive(lease, now)
+	if err != nil {
+		return err
+	}
+	if lease.Renewals >= lease.MaxRenewals {
+		return fmt.Errorf("lease %s has no renewals left", leaseID)
+	}
+	end, err := time.Parse(time.RFC3339, newEnd)
+	if err != nil {
+		return fmt.Errorf("newEnd must be an RFC3339 timestamp: %v", err)
+	}
+	current, err := time.Parse(time.RFC3339, lease.End)
+	if err != nil {
+		return fmt.Errorf("lease %s has an invalid end: %v", leaseID, err)
+	}
+	if !end.After(current) {
+		return fmt.Errorf("newEnd must be after the current end %s", lease.End)
+	}
+
+	lease.End = end.UTC().Format(time.RFC3339)
+	lease.Renewals++
+	err = putLease(ctx, lease)
+	if err != nil {
+		return err
+	}
+
+	land.LeasedUntil = lease.End
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.LeaseID = leaseID
+	return emitEvent(ctx, EventLeaseRenewed, event)
+}
+
+// Owner or lessee ends an active lease early, clearing the land's lease marker.
+// The lease is presented in transient data as "lease".
+func (c *LandContract) TerminateLease(ctx contractapi.TransactionContextInterface, leaseID string) error {
+	lease, land, err := presentedLease(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	err = requireLeaseParty(ctx, lease, land)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	err = requireLeaseActive(lease, now)
+	if err != nil {
+		return err
+	}
+
+	lease.Status = LeaseTerminated
+	lease.TerminatedAt = now.Format(time.RFC3339)
+	err = putLease(ctx, lease)
+	if err != nil {
+		return err
+	}
+
+	land.LeaseID = ""
+	land.LeasedUntil = ""
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.LeaseID = leaseID
+	return emitEvent(ctx, EventLeaseTerminated, event)
+}
+
+// Current owner or lessee reads a lease's terms. A lease past its end reads as
+// Expired. Must be evaluated on a peer of the seller or buyer orgs.
+func (c *LandContract) GetLease(ctx contractapi.TransactionContextInterface, leaseID string) (*Lease, error) {
+	leaseBytes, err := ctx.GetStub().GetPrivateData(collectionOwnerLessee, leaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease: %v", err)
+	}
+	if leaseBytes == nil {
+		return nil, fmt.Errorf("lease with ID %s does not exist", leaseID)
+	}
+	var lease Lease
+	err = json.Unmarshal(leaseBytes, &lease)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling lease: %v", err)
+	}
+
+	land, err := readLand(ctx, lease.LandID)
+	if err != nil {
+		return nil, err
+	}
+	err = requireLeaseParty(ctx, &lease, land)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if lease.Status == LeaseActive && leaseExpired(&lease, now) {
+		lease.Status = LeaseExpired
+	}
+	return &lease, nil
+}
+
+// presentedLease takes the lease the caller presents as "lease" in transient data,
+// checks it against the stored hash and loads its land, which must still carry it
+func presentedLease(ctx contractapi.TransactionContextInterface, leaseID string) (*Lease, *Land, error) {
+	lease, err := verifiedLease(ctx, leaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	land, err := readLand(ctx, lease.LandID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if land.LeaseID != leaseID {
+		return nil, nil, fmt.Errorf("lease %s is not the current lease on land %s", leaseID, land.LandID)
+	}
+	return lease, land, nil
+}
+
+// verifiedLease takes the lease the caller presents as "lease" in transient data
+// and checks it against the stored hash
+func verifiedLease(ctx contractapi.TransactionContextInterface, leaseID string) (*Lease, error) {
+	terms, err := leaseTerms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var lease Lease
+	err = json.Unmarshal(terms, &lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lease: %v", err)
+	}
+	if lease.LeaseID != leaseID {
+		return nil, fmt.Errorf("presented lease %s is not lease %s", lease.LeaseID, leaseID)
+	}
+
+	// GetLease reports a lapsed lease as Expired, but it is stored as Active
+	if lease.Status == LeaseExpired {
+		lease.Status = LeaseActive
+	}
+	// Leases are always stored as json.Marshal(Lease), so re-marshaling yields the stored bytes
+	canonical, err := json.Marshal(lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lease: %v", err)
+	}
+	storedHash, err := ctx.GetStub().GetPrivateDataHash(collectionOwnerLessee, leaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease hash: %v", err)
+	}
+	if storedHash == nil {
+		return nil, fmt.Errorf("lease with ID %s does not exist", leaseID)
+	}
+	hash := sha256.Sum256(canonical)
+	if !bytes.Equal(hash[:], storedHash) {
+		return nil, fmt.Errorf("lease %s does not match the recorded lease", leaseID)
+	}
+	return &lease, nil
+}
+
+// requireLeaseParty checks the caller is the land's current owner or the lessee
+func requireLeaseParty(ctx contractapi.TransactionContextInterface, lease *Lease, land *Land) error {
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if clientID != land.Owner && clientID != lease.LesseeID {
+		return fmt.Errorf("only the land owner or the lessee can act on lease %s", lease.LeaseID)
+	}
+	return nil
+}
+
+// requireLeaseActive checks a lease has been neither terminated nor outlived
+func requireLeaseActive(lease *Lease, now time.Time) error {
+	if lease.Status != LeaseActive {
+		return fmt.Errorf("lease %s is %s", lease.LeaseID, lease.Status)
+	}
+	if leaseExpired(lease, now) {
+		return fmt.Errorf("lease %s has expired", lease.LeaseID)
+	}
+	return nil
+}
+
+// leaseExpired is judged against the transaction time, so a lease lapses at its
+// end without anyone having to write it back
+func leaseExpired(lease *Lease, now time.Time) bool {
+	end, err := time.Parse(time.RFC3339, lease.End)
+	return err != nil || !now.Before(end)
+}
+
+// leased reports whether a land's lease marker is still in force
+func leased(land *Land, now time.Time) bool {
+	if land.LeasedUntil == "" {
+		return false
+	}
+	end, err := time.Parse(time.RFC3339, land.LeasedUntil)
+	return err == nil && now.Before(end)
+}
+
+// requireNotLeased refuses changes that a sitting lessee would not survive
+func requireNotLeased(ctx contractapi.TransactionContextInterface, land *Land) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if leased(land, now) {
+		return fmt.Errorf("land with ID %s is leased until %s", land.LandID, land.LeasedUntil)
+	}
+	return nil
+}
+
+// leaseTerms returns the "lease" transient entry
+func leaseTerms(ctx contractapi.TransactionContextInterface) ([]byte, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("error getting transient data: %v", err)
+	}
+	terms, ok := transient["lease"]
+	if !ok {
+		return nil, fmt.Errorf("lease key missing in transient data")
+	}
+	return terms, nil
+}
+
+// putLease writes a lease to the owner/lessee collection
+func putLease(ctx contractapi.TransactionContextInterface, lease *Lease) error {
+	leaseJSON, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(collectionOwnerLessee, lease.LeaseID, leaseJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store lease: %v", err)
+	}
+	return nil
+}
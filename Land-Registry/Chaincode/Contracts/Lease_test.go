@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// leaseTerms returns transient data for a year's lease of a land to the buyer
+func (l *testLedger) leaseTerms(lesseeMSP string, maxRenewals int) map[string][]byte {
+	l.t.Helper()
+	terms, err := json.Marshal(map[string]interface{}{
+		"lesseeID":    "buyer",
+		"lesseeMSP":   lesseeMSP,
+		"lesseeName":  "Buyer",
+		"start":       l.now.Format(time.RFC3339),
+		"end":         l.now.AddDate(1, 0, 0).Format(time.RFC3339),
+		"rentMinor":   1000000,
+		"rentPeriod":  "monthly",
+		"maxRenewals": maxRenewals,
+		"depositHash": "deposit",
+	})
+	l.must(err)
+	return map[string][]byte{"lease": terms}
+}
+
+// lease returns the stored lease as transient data
+func (l *testLedger) lease(leaseID string) map[string][]byte {
+	return map[string][]byte{"lease": l.privateJSON(collectionOwnerLessee, leaseID)}
+}
+
+func TestLeaseLifecycle(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+
+	l.mustFail(contract.RegisterLease(l.as(l.seller, l.leaseTerms("Org3MSP", 1)), "L1", "LS1"), "is not an org of the seller or buyer party")
+	l.must(contract.RegisterLease(l.as(l.seller, l.leaseTerms("Org2MSP", 1)), "L1", "LS1"))
+	if land := l.land("L1"); land.LeaseID != "" {
+		t.Fatalf("land carries lease %s before it was accepted", land.LeaseID)
+	}
+
+	l.mustFail(contract.AcceptLease(l.as(l.coBuyer, l.lease("LS1")), "LS1"), "only the lessee can accept lease LS1")
+	l.must(contract.AcceptLease(l.as(l.buyer, l.lease("LS1")), "LS1"))
+	end := l.now.AddDate(1, 0, 0).Format(time.RFC3339)
+	if land := l.land("L1"); land.LeaseID != "LS1" || land.LeasedUntil != end {
+		t.Fatalf("land leased as %s until %s, want LS1 until %s", land.LeaseID, land.LeasedUntil, end)
+	}
+	l.mustFail(contract.AcceptLease(l.as(l.buyer, l.lease("LS1")), "LS1"), "lease LS1 is Active")
+	l.mustFail(contract.RegisterLease(l.as(l.seller, l.leaseTerms("Org2MSP", 1)), "L1", "LS2"), "is already leased until")
+
+	children := `[{"landID":"L1A","area":0.5,"areaUnit":"acre"},{"landID":"L1B","area":0.5,"areaUnit":"acre"}]`
+	l.mustFail(contract.RequestSplit(l.as(l.seller, nil), "P1", "L1", children), "land with ID L1 is leased until "+end)
+
+	newEnd := l.now.AddDate(2, 0, 0).Format(time.RFC3339)
+	l.mustFail(contract.RenewLease(l.as(l.buyer, l.lease("LS1")), "LS1", end), "newEnd must be after the current end")
+	l.must(contract.RenewLease(l.as(l.buyer, l.lease("LS1")), "LS1", newEnd))
+	if land := l.land("L1"); land.LeasedUntil != newEnd {
+		t.Fatalf("renewed land leased until %s, want %s", land.LeasedUntil, newEnd)
+	}
+	l.mustFail(contract.RenewLease(l.as(l.seller, l.lease("LS1")), "LS1", l.now.AddDate(3, 0, 0).Format(time.RFC3339)), "lease LS1 has no renewals left")
+
+	l.mustFail(contract.TerminateLease(l.as(l.coBuyer, l.lease("LS1")), "LS1"), "only the land owner or the lessee can act on lease LS1")
+	l.must(contract.TerminateLease(l.as(l.seller, l.lease("LS1")), "LS1"))
+	if land := l.land("L1"); land.LeaseID != "" || land.LeasedUntil != "" {
+		t.Fatalf("terminated lease left land leased as %s until %s", land.LeaseID, land.LeasedUntil)
+	}
+	l.mustFail(contract.RenewLease(l.as(l.buyer, l.lease("LS1")), "LS1", newEnd), "lease LS1 is not the current lease on land L1")
+	l.must(contract.RequestSplit(l.as(l.seller, nil), "P1", "L1", children))
+}
@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	LienApproved = "Approved"
+	LienActive   = "Active"
+	LienReleased = "Released"
+)
+
+// Lien is a mortgage or other charge on a land, kept on the public ledger like an
+// encumbrance certificate. Priority 1 is the first charge.
+type Lien struct {
+	LienID       string `json:"lienID"`
+	LandID       string `json:"landID"`
+	Lender       string `json:"lender"` // client identity ID of the lienholder
+	LenderMSP    string `json:"lenderMSP"`
+	LenderCert   string `json:"lenderCert"` // PEM; verifies the lienholder's consents
+	AmountMinor  int64  `json:"amountMinor"`
+	Priority     int    `json:"priority"`
+	Status       string `json:"status"`     // Approved, Active, Released
+	ApprovedBy   string `json:"approvedBy"` // client identity ID of the owner who agreed to the lien
+	ApprovedAt   string `json:"approvedAt"`
+	RegisteredAt string `json:"registeredAt,omitempty"`
+	ReleasedAt   string `json:"releasedAt,omitempty"`
+}
+
+// LienConsent is a lienholder's signed agreement to one ListLand, RegisterToBuyer
+// or TransferShare on an encumbered land. Nonce makes each consent usable once.
+// Signature is the base64 ECDSA signature by the lienholder's key over the
+// SHA-256 of the consent's JSON with Signature left empty.
+type LienConsent struct {
+	LienID    string `json:"lienID"`
+	LandID    string `json:"landID"`
+	Action    string `json:"action"`            // ListLand, RegisterToBuyer or TransferShare
+	OfferID   string `json:"offerID,omitempty"` // the accepted offer, for RegisterToBuyer
+	Expiry    string `json:"expiry"`            // RFC3339
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Current owner agrees to a lender charging their land, e.g. when taking a loan.
+// The lien only takes effect when that lender registers it with RegisterLien for
+// the same amount.
+func (c *LandContract) ApproveLien(ctx contractapi.TransactionContextInterface, landID string, lienID string, lenderID string, lenderMSP string, amountMinor int64) error {
+	if lienID == "" || lenderID == "" {
+		return fmt.Errorf("lienID and lenderID are required")
+	}
+	if amountMinor <= 0 {
+		return fmt.Errorf("amountMinor must be a positive amount in minor currency units")
+	}
+
+	land, err := readOwnedLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if land.Status == StatusRetired {
+		return fmt.Errorf("land with ID %s has been retired", landID)
+	}
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = config.requireMSP(lenderMSP, PartyLender)
+	if err != nil {
+		return err
+	}
+
+	existing, err := readLien(ctx, landID, lienID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("lien with ID %s already exists on land %s", lienID, landID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	lien := Lien{
+		LienID:      lienID,
+		LandID:      landID,
+		Lender:      lenderID,
+		LenderMSP:   lenderMSP,
+		AmountMinor: amountMinor,
+		Status:      LienApproved,
+		ApprovedBy:  land.Owner,
+		ApprovedAt:  now.Format(time.RFC3339),
+	}
+	err = putLien(ctx, &lien)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLienApproved, LandEvent{LandID: landID, LienID: lienID})
+}
+
+// Lender registers a lien the land's owner has approved (see ApproveLien).
+// Priority follows registration order among the land's active liens.
+func (c *LandContract) RegisterLien(ctx contractapi.TransactionContextInterface, landID string, lienID string, amountMinor int64) error {
+	err := authorize(ctx, "RegisterLien")
+	if err != nil {
+		return err
+	}
+	if lienID == "" {
+		return fmt.Errorf("lienID is required")
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if land.Status == StatusRetired {
+		return fmt.Errorf("land with ID %s has been retired", landID)
+	}
+
+	lender, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+
+	lien, err := readLien(ctx, landID, lienID)
+	if err != nil {
+		return err
+	}
+	if lien == nil || lien.Lender != lender || lien.LenderMSP != msp || lien.AmountMinor != amountMinor {
+		return fmt.Errorf("lien with ID %s has not been approved by the owner of land %s for this lender and amount", lienID, landID)
+	}
+	if lien.Status != LienApproved {
+		return fmt.Errorf("lien with ID %s is already registered on land %s", lienID, landID)
+	}
+	if lien.ApprovedBy != land.Owner {
+		return fmt.Errorf("land with ID %s has changed owner since lien %s was approved", landID, lienID)
+	}
+
+	liens, err := activeLiens(ctx, landID)
+	if err != nil {
+		return err
+	}
+	priority := 1
+	for _, lien := range liens {
+		if lien.Priority >= priority {
+			priority = lien.Priority + 1
+		}
+	}
+
+	cert, err := callerCertificate(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	lien.LenderCert = cert
+	lien.Priority = priority
+	lien.Status = LienActive
+	lien.RegisteredAt = now.Format(time.RFC3339)
+	err = putLien(ctx, lien)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLienRegistered, LandEvent{LandID: landID, LienID: lienID})
+}
+
+// Lienholder releases their lien, e.g. once the loan is repaid
+func (c *LandContract) ReleaseLien(ctx contractapi.TransactionContextInterface, landID string, lienID string) error {
+	lien, err := readLienForHolder(ctx, landID, lienID)
+	if err != nil {
+		return err
+	}
+	if lien.Status != LienActive {
+		return fmt.Errorf("lien %s is already released", lienID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	lien.Status = LienReleased
+	lien.ReleasedAt = now.Format(time.RFC3339)
+	err = putLien(ctx, lien)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLienReleased, LandEvent{LandID: landID, LienID: lienID})
+}
+
+// Lienholder records their current certificate after it was renewed, so their
+// consents verify again
+func (c *LandContract) UpdateLienholderCertificate(ctx contractapi.TransactionContextInterface, landID string, lienID string) error {
+	lien, err := readLienForHolder(ctx, landID, lienID)
+	if err != nil {
+		return err
+	}
+
+	cert, err := callerCertificate(ctx)
+	if err != nil {
+		return err
+	}
+	lien.LenderCert = cert
+	err = putLien(ctx, lien)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLienholderCertificateUpdated, LandEvent{LandID: landID, LienID: lienID})
+}
+
+// Anyone lists the liens on a land, active and released, in priority order
+func (c *LandContract) GetLiens(ctx contractapi.TransactionContextInterface, landID string) ([]*Lien, error) {
+	if _, err := readLand(ctx, landID); err != nil {
+		return nil, err
+	}
+	return readLiens(ctx, landID)
+}
+
+// requireLienConsent lets an action on a land proceed only if every active lien's
+// holder has consented to it, through LienConsents passed as "lienConsents" in
+// transient data. Each consent used is spent, so it cannot be replayed.
+func requireLienConsent(ctx contractapi.TransactionContextInterface, landID string, action string, offerID string) error {
+	liens, err := activeLiens(ctx, landID)
+	if err != nil || len(liens) == 0 {
+		return err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient data: %v", err)
+	}
+	var consents []LienConsent
+	if consentData, ok := transient["lienConsents"]; ok {
+		err = json.Unmarshal(consentData, &consents)
+		if err != nil {
+			return fmt.Errorf("failed to parse lien consents: %v", err)
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	for _, lien := range liens {
+		var consent *LienConsent
+		for i := range consents {
+			if consents[i].LienID == lien.LienID && consentValid(&consents[i], lien, action, offerID, now) {
+				consent = &consents[i]
+				break
+			}
+		}
+		if consent == nil {
+			return fmt.Errorf("land with ID %s has an active lien %s without the lienholder's consent", landID, lien.LienID)
+		}
+		err = spendConsent(ctx, consent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spendConsent records a consent's nonce, refusing one that was already used
+func spendConsent(ctx contractapi.TransactionContextInterface, consent *LienConsent) error {
+	key, err := ctx.GetStub().CreateCompositeKey("lienconsent", []string{consent.LandID, consent.LienID, consent.Nonce})
+	if err != nil {
+		return fmt.Errorf("failed to create lien consent key: %v", err)
+	}
+	used, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read lien consent: %v", err)
+	}
+	if used != nil {
+		return fmt.Errorf("consent to lien %s has already been used", consent.LienID)
+	}
+	err = ctx.GetStub().PutState(key, []byte(ctx.GetStub().GetTxID()))
+	if err != nil {
+		return fmt.Errorf("failed to store lien consent: %v", err)
+	}
+	return nil
+}
+
+// consentValid checks a consent covers this action and was signed by the lienholder
+func consentValid(consent *LienConsent, lien *Lien, action string, offerID string, now time.Time) bool {
+	if consent.LandID != lien.LandID || consent.Action != action || consent.OfferID != offerID || consent.Nonce == "" {
+		return false
+	}
+	expiry, err := time.Parse(time.RFC3339, consent.Expiry)
+	if err != nil || !now.Before(expiry) {
+		return false
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(consent.Signature)
+	if err != nil {
+		return false
+	}
+	unsigned := *consent
+	unsigned.Signature = ""
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return false
+	}
+
+	block, _ := pem.Decode([]byte(lien.LenderCert))
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	publicKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	digest := sha256.Sum256(payload)
+	return ecdsa.VerifyASN1(publicKey, digest[:], signature)
+}
+
+// requireNoActiveLiens refuses changes that would leave a land's liens behind
+func requireNoActiveLiens(ctx contractapi.TransactionContextInterface, landID string) error {
+	liens, err := activeLiens(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if len(liens) > 0 {
+		return fmt.Errorf("land with ID %s has active liens", landID)
+	}
+	return nil
+}
+
+// readLien loads a lien, or nil when there is none under its ID
+func readLien(ctx contractapi.TransactionContextInterface, landID string, lienID string) (*Lien, error) {
+	key, err := lienKey(ctx, landID, lienID)
+	if err != nil {
+		return nil, err
+	}
+	lienBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lien: %v", err)
+	}
+	if lienBytes == nil {
+		return nil, nil
+	}
+
+	var lien Lien
+	err = json.Unmarshal(lienBytes, &lien)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling lien: %v", err)
+	}
+	return &lien, nil
+}
+
+// readLienForHolder loads a registered lien and checks the caller is its holder
+func readLienForHolder(ctx contractapi.TransactionContextInterface, landID string, lienID string) (*Lien, error) {
+	lien, err := readLien(ctx, landID, lienID)
+	if err != nil {
+		return nil, err
+	}
+	if lien == nil || lien.Status == LienApproved {
+		return nil, fmt.Errorf("lien with ID %s does not exist on land %s", lienID, landID)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if lien.Lender != clientID {
+		return nil, fmt.Errorf("only the lienholder can change lien %s", lienID)
+	}
+	return lien, nil
+}
+
+// readLiens returns every lien on a land in priority order
+func readLiens(ctx contractapi.TransactionContextInterface, landID string) ([]*Lien, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("lien", []string{landID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read liens: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	liens := []*Lien{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var lien Lien
+		err = json.Unmarshal(queryResponse.Value, &lien)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling lien: %v", err)
+		}
+		liens = append(liens, &lien)
+	}
+
+	sort.SliceStable(liens, func(i, j int) bool {
+		if liens[i].Status != liens[j].Status {
+			return liens[i].Status == LienActive
+		}
+		return liens[i].Priority < liens[j].Priority
+	})
+	return liens, nil
+}
+
+// activeLiens returns the liens on a land that have not been released
+func activeLiens(ctx contractapi.TransactionContextInterface, landID string) ([]*Lien, error) {
+	liens, err := readLiens(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+	active := []*Lien{}
+	for _, lien := range liens {
+		if lien.Status == LienActive {
+			active = append(active, lien)
+		}
+	}
+	return active, nil
+}
+
+// putLien writes a lien to the public ledger
+func putLien(ctx contractapi.TransactionContextInterface, lien *Lien) error {
+	lienJSON, err := json.Marshal(lien)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lien: %v", err)
+	}
+	key, err := lienKey(ctx, lien.LandID, lien.LienID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, lienJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store lien: %v", err)
+	}
+	return nil
+}
+
+func lienKey(ctx contractapi.TransactionContextInterface, landID string, lienID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("lien", []string{landID, lienID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create lien key: %v", err)
+	}
+	return key, nil
+}
+
+// callerCertificate returns the submitting client's certificate as PEM
+func callerCertificate(ctx contractapi.TransactionContextInterface) (string, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil || cert == nil {
+		return "", fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})), nil
+}
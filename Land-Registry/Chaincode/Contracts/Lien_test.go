@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// lienConsents signs a lienholder's consent and returns it as transient data
+func (l *testLedger) lienConsents(lender *testIdentity, consent LienConsent) map[string][]byte {
+	l.t.Helper()
+	payload, err := json.Marshal(consent)
+	l.must(err)
+	digest := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, lender.key, digest[:])
+	l.must(err)
+	consent.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	consents, err := json.Marshal([]LienConsent{consent})
+	l.must(err)
+	return map[string][]byte{"lienConsents": consents}
+}
+
+// relist has the seller list a land they own again
+func (l *testLedger) relist(landID string, transient map[string][]byte) error {
+	contract := &LandContract{}
+	return contract.ListLand(l.as(l.seller, transient), landID, "Village Road", 1, UnitAcre, "Agricultural", "Loam", "Well", "NH44", "Nagpur", "21.1,79.0", 100000000)
+}
+
+func TestLienNeedsOwnerApproval(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+
+	l.mustFail(contract.RegisterLien(l.as(l.lender, nil), "L1", "LN1", 500000), "has not been approved by the owner")
+	l.mustFail(contract.ApproveLien(l.as(l.buyer, nil), "L1", "LN1", "lender", "Org2MSP", 500000), "only the current owner")
+	l.mustFail(contract.ApproveLien(l.as(l.seller, nil), "L1", "LN1", "lender", "Org3MSP", 500000), "is not an org of the lender party")
+	l.must(contract.ApproveLien(l.as(l.seller, nil), "L1", "LN1", "lender", "Org2MSP", 500000))
+
+	l.mustFail(contract.RegisterLien(l.as(l.lender, nil), "L1", "LN1", 900000), "has not been approved by the owner")
+	other := newTestIdentity(t, "other-lender", "Org2MSP", RoleLender)
+	l.mustFail(contract.RegisterLien(l.as(other, nil), "L1", "LN1", 500000), "has not been approved by the owner")
+	l.must(contract.RegisterLien(l.as(l.lender, nil), "L1", "LN1", 500000))
+	l.mustFail(contract.RegisterLien(l.as(l.lender, nil), "L1", "LN1", 500000), "is already registered")
+
+	liens, err := readLiens(l.as(l.registrar, nil), "L1")
+	l.must(err)
+	if len(liens) != 1 || liens[0].Status != LienActive || liens[0].Priority != 1 || liens[0].LenderCert == "" {
+		t.Fatalf("unexpected liens after registration: %+v", liens)
+	}
+}
+
+func TestLienConsentIsSingleUse(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+	l.must(contract.ApproveLien(l.as(l.seller, nil), "L1", "LN1", "lender", "Org2MSP", 500000))
+	l.must(contract.RegisterLien(l.as(l.lender, nil), "L1", "LN1", 500000))
+
+	consent := LienConsent{
+		LienID: "LN1",
+		LandID: "L1",
+		Action: "ListLand",
+		Expiry: l.now.Add(time.Hour).Format(time.RFC3339),
+		Nonce:  "n1",
+	}
+	l.mustFail(l.relist("L1", nil), "without the lienholder's consent")
+	wrongAction := consent
+	wrongAction.Action = "RegisterToBuyer"
+	l.mustFail(l.relist("L1", l.lienConsents(l.lender, wrongAction)), "without the lienholder's consent")
+	l.mustFail(l.relist("L1", l.lienConsents(l.buyer, consent)), "without the lienholder's consent")
+
+	l.must(l.relist("L1", l.lienConsents(l.lender, consent)))
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+	l.mustFail(l.relist("L1", l.lienConsents(l.lender, consent)), "has already been used")
+
+	consent.Nonce = "n2"
+	l.must(l.relist("L1", l.lienConsents(l.lender, consent)))
+
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+	l.must(contract.ReleaseLien(l.as(l.lender, nil), "L1", "LN1"))
+	l.must(l.relist("L1", nil))
+}
+
+func TestLienConsentExpires(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+	l.must(contract.ApproveLien(l.as(l.seller, nil), "L1", "LN1", "lender", "Org2MSP", 500000))
+	l.must(contract.RegisterLien(l.as(l.lender, nil), "L1", "LN1", 500000))
+
+	consents := l.lienConsents(l.lender, LienConsent{
+		LienID: "LN1",
+		LandID: "L1",
+		Action: "ListLand",
+		Expiry: l.now.Add(time.Hour).Format(time.RFC3339),
+		Nonce:  "n1",
+	})
+	l.advance(2 * time.Hour)
+	l.mustFail(l.relist("L1", consents), "without the lienholder's consent")
+}
@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// legacyLand holds the free-form fields lands were written with before area and price were typed
+type legacyLand struct {
+	Size         string `json:"size"`
+	SellingPrice string `json:"sellingPrice"`
+}
+
+// MigrationFailure names a land that could not be converted and why
+type MigrationFailure struct {
+	LandID string `json:"landID"`
+	Reason string `json:"reason"`
+}
+
+// MigrationResult reports one batch of MigrateLandRecords. NextKey is empty once every key has been visited.
+// Unowned lists lands written before owners were tracked, for AssignLegacyOwner.
+type MigrationResult struct {
+	Migrated []string            `json:"migrated"`
+	Failed   []*MigrationFailure `json:"failed"`
+	Unowned  []string            `json:"unowned"`
+	NextKey  string              `json:"nextKey"`
+}
+
+// Land Registry (Org3) converts lands stored with free-form size and sellingPrice
+// text into typed area and price. It visits at most batchSize keys from startKey;
+// call again with NextKey until it comes back empty. Records that cannot be parsed
+// are left untouched and reported so they can be corrected by hand.
+func (c *LandContract) MigrateLandRecords(ctx contractapi.TransactionContextInterface, startKey string, batchSize int32) (*MigrationResult, error) {
+	err := authorize(ctx, "MigrateLandRecords")
+	if err != nil {
+		return nil, err
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batchSize must be positive")
+	}
+
+	// Every record other than a land is stored under a composite key, which range
+	// scans never return, so the scan visits lands only
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lands: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	result := MigrationResult{Migrated: []string{}, Failed: []*MigrationFailure{}, Unowned: []string{}}
+	var visited int32
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if visited == batchSize {
+			result.NextKey = queryResponse.Key
+			break
+		}
+		visited++
+
+		var land Land
+		err = json.Unmarshal(queryResponse.Value, &land)
+		if err != nil {
+			result.Failed = append(result.Failed, &MigrationFailure{LandID: queryResponse.Key, Reason: err.Error()})
+			continue
+		}
+		if land.Owner == "" && land.Status != StatusRetired {
+			result.Unowned = append(result.Unowned, queryResponse.Key)
+		}
+
+		var legacy legacyLand
+		if err := json.Unmarshal(queryResponse.Value, &legacy); err != nil || (legacy.Size == "" && legacy.SellingPrice == "") {
+			continue // already migrated
+		}
+
+		if err := migrateLand(&land, &legacy); err != nil {
+			result.Failed = append(result.Failed, &MigrationFailure{LandID: queryResponse.Key, Reason: err.Error()})
+			continue
+		}
+
+		err = putLand(ctx, &land)
+		if err != nil {
+			return nil, err
+		}
+		result.Migrated = append(result.Migrated, land.LandID)
+	}
+
+	if len(result.Migrated) > 0 {
+		err = emitEvent(ctx, EventLandRecordsMigrated, LandEvent{LandIDs: result.Migrated})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
+
+// Land Registry (Org3) gives a land written before owners were tracked its owner,
+// once the title has been checked off-chain. Until then the land cannot be
+// re-listed, sold or managed by anyone. ownerMSP must be a seller or buyer org.
+func (c *LandContract) AssignLegacyOwner(ctx contractapi.TransactionContextInterface, landID string, ownerID string, ownerMSP string) error {
+	err := authorize(ctx, "AssignLegacyOwner")
+	if err != nil {
+		return err
+	}
+	if ownerID == "" || ownerMSP == "" {
+		return fmt.Errorf("ownerID and ownerMSP are required")
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if land.Owner != "" {
+		return fmt.Errorf("land with ID %s already has an owner", landID)
+	}
+	if land.Status == StatusRetired {
+		return fmt.Errorf("land with ID %s has been retired", landID)
+	}
+
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = config.requireMSP(ownerMSP, PartySeller, PartyBuyer)
+	if err != nil {
+		return err
+	}
+
+	land.Owner = ownerID
+	land.OwnerMSP = ownerMSP
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+	err = setLandEndorsement(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventLegacyOwnerAssigned, landEvent(land))
+}
+
+// migrateLand fills typed area and price from legacy text
+func migrateLand(land *Land, legacy *legacyLand) error {
+	area, unit, err := parseLegacyArea(legacy.Size)
+	if err != nil {
+		return err
+	}
+	err = setLandArea(land, area, unit)
+	if err != nil {
+		return fmt.Errorf("size %q: %v", legacy.Size, err)
+	}
+
+	priceMinor, err := parseLegacyPrice(legacy.SellingPrice)
+	if err != nil {
+		return err
+	}
+	return setLandPrice(land, priceMinor)
+}
+
+var legacyNumber = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*(.*)$`)
+
+// legacyUnits maps how sizes were written (lowercased, without spaces or dots) to area units
+var legacyUnits = map[string]string{
+	"sqft": UnitSqft, "sft": UnitSqft, "ft2": UnitSqft, "squarefeet": UnitSqft, "squarefoot": UnitSqft,
+	"sqm": UnitSqm, "m2": UnitSqm, "squaremetre": UnitSqm, "squaremetres": UnitSqm, "squaremeter": UnitSqm, "squaremeters": UnitSqm,
+	"acre": UnitAcre, "acres": UnitAcre, "ac": UnitAcre,
+	"hectare": UnitHectare, "hectares": UnitHectare, "ha": UnitHectare,
+	"cent": UnitCent, "cents": UnitCent,
+	"guntha": UnitGuntha, "gunthas": UnitGuntha, "gunta": UnitGuntha, "guntas": UnitGuntha,
+}
+
+// parseLegacyArea reads sizes such as "5 acres", "1,200 sq.ft" or "2 hectare"
+func parseLegacyArea(size string) (float64, string, error) {
+	text := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(size)), ",", "")
+	match := legacyNumber.FindStringSubmatch(text)
+	if match == nil {
+		return 0, "", fmt.Errorf("size %q has no leading number", size)
+	}
+
+	area, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("size %q: %v", size, err)
+	}
+
+	unitText := strings.NewReplacer(" ", "", ".", "").Replace(match[2])
+	unit, ok := legacyUnits[unitText]
+	if !ok {
+		return 0, "", fmt.Errorf("size %q has no recognised unit", size)
+	}
+
+	return area, unit, nil
+}
+
+// parseLegacyPrice reads prices such as "500000", "Rs. 5,00,000/-" or "12.5 lakh" into paise
+func parseLegacyPrice(price string) (int64, error) {
+	text := strings.ToLower(strings.TrimSpace(price))
+	text = strings.NewReplacer("₹", "", "/-", "", ",", "").Replace(text)
+	for _, prefix := range []string{"inr", "rs.", "rs"} {
+		text = strings.TrimPrefix(strings.TrimSpace(text), prefix)
+	}
+	text = strings.TrimSpace(text)
+
+	multiplier := 1.0
+	for _, scale := range []struct {
+		suffix string
+		factor float64
+	}{{"lakhs", 1e5}, {"lakh", 1e5}, {"crores", 1e7}, {"crore", 1e7}} {
+		if strings.HasSuffix(text, scale.suffix) {
+			multiplier = scale.factor
+			text = strings.TrimSpace(strings.TrimSuffix(text, scale.suffix))
+			break
+		}
+	}
+
+	amount, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("sellingPrice %q is not a number", price)
+	}
+
+	return int64(math.Round(amount * multiplier * 100)), nil
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLegacyArea(t *testing.T) {
+	tests := []struct {
+		size     string
+		wantArea float64
+		wantUnit string
+		wantErr  bool
+	}{
+		{size: "5 acres", wantArea: 5, wantUnit: UnitAcre},
+		{size: "1,200 sq.ft", wantArea: 1200, wantUnit: UnitSqft},
+		{size: "2 Hectare", wantArea: 2, wantUnit: UnitHectare},
+		{size: "0.5ha", wantArea: 0.5, wantUnit: UnitHectare},
+		{size: "10 guntas", wantArea: 10, wantUnit: UnitGuntha},
+		{size: "350 Square Metres", wantArea: 350, wantUnit: UnitSqm},
+		{size: "acres", wantErr: true},
+		{size: "5 bigha", wantErr: true},
+		{size: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		area, unit, err := parseLegacyArea(test.size)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseLegacyArea(%q) = %g %s, want an error", test.size, area, unit)
+			}
+			continue
+		}
+		if err != nil || area != test.wantArea || unit != test.wantUnit {
+			t.Errorf("parseLegacyArea(%q) = %g %s, %v, want %g %s", test.size, area, unit, err, test.wantArea, test.wantUnit)
+		}
+	}
+}
+
+func TestParseLegacyPrice(t *testing.T) {
+	tests := []struct {
+		price   string
+		want    int64
+		wantErr bool
+	}{
+		{price: "500000", want: 50000000},
+		{price: "Rs. 5,00,000/-", want: 50000000},
+		{price: "₹ 1,250.50", want: 125050},
+		{price: "INR 12.5 lakh", want: 125000000},
+		{price: "2 crores", want: 2000000000},
+		{price: "negotiable", wantErr: true},
+		{price: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseLegacyPrice(test.price)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseLegacyPrice(%q) = %d, want an error", test.price, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("parseLegacyPrice(%q) = %d, %v, want %d", test.price, got, err, test.want)
+		}
+	}
+}
+
+func TestMigrateLandRecords(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	admin := newTestIdentity(t, "admin", "Org3MSP", RoleAdmin)
+
+	ctx := l.as(l.registrar, nil)
+	l.must(ctx.GetStub().PutState("A", []byte(`{"landID":"A","size":"2.5 acres","sellingPrice":"Rs. 12,00,000/-","status":"For Sale","owner":"seller","ownerMSP":"Org1MSP"}`)))
+	l.must(ctx.GetStub().PutState("B", []byte(`{"landID":"B","size":"a few bighas","sellingPrice":"500000","status":"For Sale","owner":"seller","ownerMSP":"Org1MSP"}`)))
+	l.must(ctx.GetStub().PutState("C", []byte(`{"landID":"C","size":"1200 sqft","sellingPrice":"9 lakh","status":"For Sale"}`)))
+	l.listLand("D")
+
+	l.mustFail(func() error {
+		_, err := contract.MigrateLandRecords(l.as(l.registrar, nil), "", 10)
+		return err
+	}(), "only a LandRegistry admin")
+
+	first, err := contract.MigrateLandRecords(l.as(admin, nil), "", 2)
+	l.must(err)
+	if !reflect.DeepEqual(first.Migrated, []string{"A"}) || len(first.Failed) != 1 || first.Failed[0].LandID != "B" || first.NextKey != "C" {
+		t.Fatalf("unexpected first batch: %+v", first)
+	}
+
+	second, err := contract.MigrateLandRecords(l.as(admin, nil), first.NextKey, 2)
+	l.must(err)
+	if !reflect.DeepEqual(second.Migrated, []string{"C"}) || len(second.Failed) != 0 || !reflect.DeepEqual(second.Unowned, []string{"C"}) || second.NextKey != "" {
+		t.Fatalf("unexpected second batch: %+v", second)
+	}
+
+	land := l.land("A")
+	if land.Area != 2.5 || land.AreaUnit != UnitAcre || land.AreaSqm != 10117.1411 || land.PriceMinor != 120000000 {
+		t.Fatalf("land A migrated to %g %s (%g sqm) at %d", land.Area, land.AreaUnit, land.AreaSqm, land.PriceMinor)
+	}
+
+	again, err := contract.MigrateLandRecords(l.as(admin, nil), "", 10)
+	l.must(err)
+	if len(again.Migrated) != 0 || len(again.Failed) != 1 {
+		t.Fatalf("a second pass migrated %v and failed %d", again.Migrated, len(again.Failed))
+	}
+}
@@ -0,0 +1,578 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const collectionBuyerSeller = "collectionBuyerSeller"
+
+const (
+	OfferPending   = "Pending"
+	OfferAccepted  = "Accepted"
+	OfferRejected  = "Rejected"
+	OfferWithdrawn = "Withdrawn"
+	OfferExpired   = "Expired"
+)
+
+// Offer is a buyer's request to buy a land, kept private between Buyer and Seller
+type Offer struct {
+	DocType    string    `json:"docType"`
+	OfferID    string    `json:"offerID"`
+	LandID     string    `json:"landID"`
+	BuyerID    string    `json:"buyerID"`
+	BuyerMSP   string    `json:"buyerMSP"`
+	BuyerName  string    `json:"buyerName"`
+	Aadhar     string    `json:"aadhar"`
+	PriceMinor int64     `json:"priceMinor"` // offered price in minor currency units (paise)
+	Expiry     string    `json:"expiry"`     // RFC3339
+	CreatedAt  string    `json:"createdAt"`
+	Status     string    `json:"status"`             // Pending, Accepted, Rejected, Withdrawn, Expired
+	CoBuyers   []CoBuyer `json:"coBuyers,omitempty"` // buying jointly; the buyer keeps the remaining share
+	Agent      string    `json:"agent,omitempty"`    // attorney who made the offer for the buyer
+}
+
+// Buyer (Org2) sends private request to buy land. An attorney holding "buy" power
+// sets onBehalfOf to the buyer's identity, and the offer is made in their name.
+func (c *LandContract) RequestToBuy(ctx contractapi.TransactionContextInterface, offerID string) error {
+	err := authorize(ctx, "RequestToBuy")
+	if err != nil {
+		return err
+	}
+	msp, _ := ctx.GetClientIdentity().GetMSPID()
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient data: %v", err)
+	}
+	privateData, ok := transient["buyerRequest"]
+	if !ok {
+		return fmt.Errorf("buyerRequest key missing in transient data")
+	}
+
+	var request struct {
+		LandID     string    `json:"landID"`
+		BuyerName  string    `json:"buyerName"`
+		Aadhar     string    `json:"aadhar"`
+		PriceMinor int64     `json:"priceMinor"`
+		Expiry     string    `json:"expiry"`
+		CoBuyers   []CoBuyer `json:"coBuyers"`
+		OnBehalfOf string    `json:"onBehalfOf"`
+	}
+	err = json.Unmarshal(privateData, &request)
+	if err != nil {
+		return fmt.Errorf("failed to parse buyer request: %v", err)
+	}
+	if request.LandID == "" || request.Expiry == "" {
+		return fmt.Errorf("landID and expiry are required in buyerRequest")
+	}
+	if request.PriceMinor <= 0 {
+		return fmt.Errorf("priceMinor must be a positive amount in minor currency units")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiry, err := time.Parse(time.RFC3339, request.Expiry)
+	if err != nil {
+		return fmt.Errorf("expiry must be an RFC3339 timestamp: %v", err)
+	}
+	if !expiry.After(now) {
+		return fmt.Errorf("offer expiry %s is already in the past", request.Expiry)
+	}
+
+	land, err := readLand(ctx, request.LandID)
+	if err != nil {
+		return err
+	}
+	if land.Status != StatusForSale {
+		return fmt.Errorf("land with ID %s is not listed for sale", request.LandID)
+	}
+	err = requireNotFrozen(ctx, request.LandID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetPrivateData(collectionBuyerSeller, offerID)
+	if err != nil {
+		return fmt.Errorf("failed to read offer: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("offer with ID %s already exists", offerID)
+	}
+
+	buyerID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	agent := ""
+	if request.OnBehalfOf != "" && request.OnBehalfOf != buyerID {
+		grant, err := actingAgent(ctx, "RequestToBuy", request.LandID, request.OnBehalfOf)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			return fmt.Errorf("only an attorney of %s can make an offer in their name on land %s", request.OnBehalfOf, request.LandID)
+		}
+		agent = grant.Agent
+		buyerID = grant.Principal
+		msp = grant.PrincipalMSP
+	}
+
+	offer := Offer{
+		DocType:    "offer",
+		OfferID:    offerID,
+		LandID:     request.LandID,
+		BuyerID:    buyerID,
+		BuyerMSP:   msp,
+		BuyerName:  request.BuyerName,
+		Aadhar:     request.Aadhar,
+		PriceMinor: request.PriceMinor,
+		Expiry:     expiry.UTC().Format(time.RFC3339),
+		CreatedAt:  now.Format(time.RFC3339),
+		Status:     OfferPending,
+		CoBuyers:   request.CoBuyers,
+		Agent:      agent,
+	}
+	if len(offer.CoBuyers) > 0 {
+		err = validateShares(offerShares(&offer))
+		if err != nil {
+			return err
+		}
+		config, err := readOrgConfig(ctx)
+		if err != nil {
+			return err
+		}
+		// Co-buyers' orgs go into the parcel's endorsement policy once it transfers
+		for _, coBuyer := range offer.CoBuyers {
+			err = config.requireMSP(coBuyer.BuyerMSP, PartySeller, PartyBuyer)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = putOffer(ctx, &offer)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventOfferCreated, LandEvent{LandID: offer.LandID, OfferID: offer.OfferID, Agent: agent})
+}
+
+// Seller (current owner or their attorney) accepts a pending offer on their land.
+// The offer is presented in transient data (see presentedOffer) because the
+// registry's peers endorse the change to the land but cannot read the offer.
+func (c *LandContract) AcceptOffer(ctx contractapi.TransactionContextInterface, offerID string) error {
+	err := authorize(ctx, "ManageLand")
+	if err != nil {
+		return err
+	}
+	offer, err := presentedOffer(ctx, offerID)
+	if err != nil {
+		return err
+	}
+	land, agent, err := readManagedLand(ctx, "AcceptOffer", offer.LandID)
+	if err != nil {
+		return err
+	}
+	if land.Status != StatusForSale {
+		return fmt.Errorf("land with ID %s is not listed for sale", land.LandID)
+	}
+	err = requireNotFrozen(ctx, land.LandID)
+	if err != nil {
+		return err
+	}
+	if err := requireOfferStatus(ctx, offer, OfferPending); err != nil {
+		return err
+	}
+
+	// An earlier acceptance still holds until its offer expires. Its expiry is
+	// kept on the land, since this peer may not be able to read the offer.
+	if land.AcceptedOfferID != "" {
+		now, err := txTime(ctx)
+		if err != nil {
+			return err
+		}
+		if !acceptanceLapsed(land, now) {
+			return fmt.Errorf("offer %s has already been accepted for land %s", land.AcceptedOfferID, land.LandID)
+		}
+	}
+
+	offer.Status = OfferAccepted
+	land.AcceptedOfferID = offer.OfferID
+	land.AcceptedExpiry = offer.Expiry
+
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	err = putOffer(ctx, offer)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventOfferAccepted, LandEvent{LandID: offer.LandID, OfferID: offer.OfferID, Agent: agent})
+}
+
+// Seller (current owner or their attorney) rejects a pending offer on their land
+func (c *LandContract) RejectOffer(ctx contractapi.TransactionContextInterface, offerID string) error {
+	err := authorize(ctx, "ManageLand")
+	if err != nil {
+		return err
+	}
+	offer, err := readOffer(ctx, offerID)
+	if err != nil {
+		return err
+	}
+	_, agent, err := readManagedLand(ctx, "RejectOffer", offer.LandID)
+	if err != nil {
+		return err
+	}
+	if err := requireOfferStatus(ctx, offer, OfferPending); err != nil {
+		return err
+	}
+
+	offer.Status = OfferRejected
+
+	err = putOffer(ctx, offer)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventOfferRejected, LandEvent{LandID: offer.LandID, OfferID: offer.OfferID, Agent: agent})
+}
+
+// Buyer (Org2), or their attorney, withdraws their offer before the land is
+// registered to them. Like AcceptOffer it takes the offer from transient data,
+// since withdrawing an accepted offer changes the land.
+func (c *LandContract) WithdrawOffer(ctx contractapi.TransactionContextInterface, offerID string) error {
+	err := authorize(ctx, "WithdrawOffer")
+	if err != nil {
+		return err
+	}
+
+	offer, err := presentedOffer(ctx, offerID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	agent := ""
+	if offer.BuyerID != clientID {
+		grant, err := actingAgent(ctx, "WithdrawOffer", offer.LandID, offer.BuyerID)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			return fmt.Errorf("only the buyer who made offer %s or their attorney can withdraw it", offerID)
+		}
+		agent = grant.Agent
+	}
+	if err := requireOfferStatus(ctx, offer, OfferPending, OfferAccepted); err != nil {
+		return err
+	}
+
+	if offer.Status == OfferAccepted {
+		land, err := readLand(ctx, offer.LandID)
+		if err != nil {
+			return err
+		}
+		if land.AcceptedOfferID == offer.OfferID {
+			land.AcceptedOfferID = ""
+			land.AcceptedExpiry = ""
+			err = putLand(ctx, land)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	offer.Status = OfferWithdrawn
+
+	err = putOffer(ctx, offer)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventOfferWithdrawn, LandEvent{LandID: offer.LandID, OfferID: offer.OfferID, Agent: agent})
+}
+
+// Buyer who made an offer, or the owner of the land, reads a single offer; so can
+// an attorney of either
+func (c *LandContract) GetOffer(ctx contractapi.TransactionContextInterface, offerID string) (*Offer, error) {
+	offer, err := readOffer(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	land, err := readLand(ctx, offer.LandID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if offer.BuyerID == clientID || land.Owner == clientID {
+		return offer, nil
+	}
+
+	grant, err := activeGrant(ctx, offer.LandID, land.Owner, clientID, ScopeAccept)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil {
+		grant, err = activeGrant(ctx, offer.LandID, offer.BuyerID, clientID, ScopeBuy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if grant == nil {
+		return nil, fmt.Errorf("only the buyer, the land owner or their attorneys can view offer %s", offerID)
+	}
+
+	return offer, nil
+}
+
+// Seller (current owner or their attorney) views every offer on their land; a Buyer
+// (Org2) views only their own and those they made as an attorney
+func (c *LandContract) GetOffersForLand(ctx contractapi.TransactionContextInterface, landID string) ([]*Offer, error) {
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := land.Owner == clientID
+	if !isOwner {
+		grant, err := activeGrant(ctx, landID, land.Owner, clientID, ScopeAccept)
+		if err != nil {
+			return nil, err
+		}
+		isOwner = grant != nil
+	}
+	if !isOwner {
+		err = authorize(ctx, "GetOffersForLand")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	offers, err := queryOffersForLand(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var visible []*Offer
+	for _, offer := range offers {
+		if !isOwner && offer.BuyerID != clientID && offer.Agent != clientID {
+			continue
+		}
+		if offerExpired(offer, now) {
+			offer.Status = OfferExpired
+		}
+		visible = append(visible, offer)
+	}
+
+	return visible, nil
+}
+
+// readOffer loads an offer from the Buyer/Seller private collection
+func readOffer(ctx contractapi.TransactionContextInterface, offerID string) (*Offer, error) {
+	offerBytes, err := ctx.GetStub().GetPrivateData(collectionBuyerSeller, offerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offer: %v", err)
+	}
+	if offerBytes == nil {
+		return nil, fmt.Errorf("offer with ID %s does not exist", offerID)
+	}
+
+	var offer Offer
+	err = json.Unmarshal(offerBytes, &offer)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling offer data: %v", err)
+	}
+
+	return &offer, nil
+}
+
+// putOffer writes an offer to the Buyer/Seller private collection
+func putOffer(ctx contractapi.TransactionContextInterface, offer *Offer) error {
+	offerJSON, err := json.Marshal(offer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal offer: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(collectionBuyerSeller, offer.OfferID, offerJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store offer: %v", err)
+	}
+
+	return nil
+}
+
+// queryOffersForLand returns every offer recorded for a land
+func queryOffersForLand(ctx contractapi.TransactionContextInterface, landID string) ([]*Offer, error) {
+	query := fmt.Sprintf(`{"selector":{"docType":"offer","landID":%q}}`, landID)
+	resultsIterator, err := ctx.GetStub().GetPrivateDataQueryResult(collectionBuyerSeller, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query offers: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var offers []*Offer
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var offer Offer
+		err = json.Unmarshal(queryResponse.Value, &offer)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, &offer)
+	}
+
+	return offers, nil
+}
+
+// verifyAcceptedOffer checks a presented offer is byte-for-byte the accepted offer
+// stored for the land. Callers outside collectionBuyerSeller can only see its hash.
+func verifyAcceptedOffer(ctx contractapi.TransactionContextInterface, land *Land, offerData []byte) (*Offer, error) {
+	offer, err := verifyOffer(ctx, offerData)
+	if err != nil {
+		return nil, err
+	}
+	if offer.OfferID != land.AcceptedOfferID || offer.LandID != land.LandID {
+		return nil, fmt.Errorf("offer %s is not the accepted offer for land %s", offer.OfferID, land.LandID)
+	}
+
+	if err := requireOfferStatus(ctx, offer, OfferAccepted); err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// presentedOffer reads the offer the caller passes as "offer" in transient data.
+// Every endorsing peer checks it against the hash it holds, so the result is the
+// same on peers outside collectionBuyerSeller, which cannot read the offer itself.
+func presentedOffer(ctx contractapi.TransactionContextInterface, offerID string) (*Offer, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("error getting transient data: %v", err)
+	}
+	offerData, ok := transient["offer"]
+	if !ok {
+		return nil, fmt.Errorf("offer key missing in transient data")
+	}
+
+	offer, err := verifyOffer(ctx, offerData)
+	if err != nil {
+		return nil, err
+	}
+	if offer.OfferID != offerID {
+		return nil, fmt.Errorf("presented offer %s is not offer %s", offer.OfferID, offerID)
+	}
+
+	return offer, nil
+}
+
+// verifyOffer parses a presented offer and checks it against the stored offer's hash
+func verifyOffer(ctx contractapi.TransactionContextInterface, offerData []byte) (*Offer, error) {
+	var offer Offer
+	err := json.Unmarshal(offerData, &offer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse offer: %v", err)
+	}
+
+	// Offers are always stored as json.Marshal(Offer), so re-marshaling yields the stored bytes
+	canonical, err := json.Marshal(offer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal offer: %v", err)
+	}
+	storedHash, err := ctx.GetStub().GetPrivateDataHash(collectionBuyerSeller, offer.OfferID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offer hash: %v", err)
+	}
+	if storedHash == nil {
+		return nil, fmt.Errorf("offer with ID %s does not exist", offer.OfferID)
+	}
+	hash := sha256.Sum256(canonical)
+	if !bytes.Equal(hash[:], storedHash) {
+		return nil, fmt.Errorf("offer %s does not match the recorded offer", offer.OfferID)
+	}
+
+	return &offer, nil
+}
+
+// requireOfferStatus checks an offer is in one of the given states and has not expired.
+// Expiry is judged against the transaction timestamp, so a lapsed offer reads as
+// Expired without anyone having to write it back.
+func requireOfferStatus(ctx contractapi.TransactionContextInterface, offer *Offer, allowed ...string) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if offerExpired(offer, now) {
+		return fmt.Errorf("offer %s has expired", offer.OfferID)
+	}
+
+	for _, status := range allowed {
+		if offer.Status == status {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("offer %s is %s", offer.OfferID, offer.Status)
+}
+
+func offerExpired(offer *Offer, now time.Time) bool {
+	if offer.Status != OfferPending && offer.Status != OfferAccepted {
+		return false
+	}
+	expiry, err := time.Parse(time.RFC3339, offer.Expiry)
+	if err != nil {
+		return false
+	}
+	return !now.Before(expiry)
+}
+
+// acceptanceLapsed reports whether the offer accepted for a land has expired
+func acceptanceLapsed(land *Land, now time.Time) bool {
+	expiry, err := time.Parse(time.RFC3339, land.AcceptedExpiry)
+	if err != nil {
+		return false
+	}
+	return !now.Before(expiry)
+}
+
+// txTime returns the transaction timestamp, which is the same on every endorsing peer
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return ts.AsTime().UTC(), nil
+}
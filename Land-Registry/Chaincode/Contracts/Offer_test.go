@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOfferAcceptHoldsUntilExpiry(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.requestToBuy(l.buyer, "O1", "L1", nil)
+	l.requestToBuy(l.coBuyer, "O2", "L1", nil)
+
+	l.mustFail(contract.AcceptOffer(l.as(l.buyer, l.offer("O1")), "O1"), "only the current owner or their attorney")
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"))
+
+	land := l.land("L1")
+	if land.AcceptedOfferID != "O1" {
+		t.Fatalf("accepted offer is %q, want O1", land.AcceptedOfferID)
+	}
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"), "offer O1 is Accepted")
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, l.offer("O2")), "O2"), "has already been accepted")
+
+	// Once O1 lapses the seller may accept another live offer; O2 lapsed with it
+	l.advance(25 * time.Hour)
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, l.offer("O2")), "O2"), "offer O2 has expired")
+	l.requestToBuy(l.coBuyer, "O3", "L1", nil)
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O3")), "O3"))
+	if land := l.land("L1"); land.AcceptedOfferID != "O3" {
+		t.Fatalf("accepted offer is %q, want O3", land.AcceptedOfferID)
+	}
+}
+
+func TestOfferRejectAndWithdraw(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.requestToBuy(l.buyer, "O1", "L1", nil)
+	l.requestToBuy(l.coBuyer, "O2", "L1", nil)
+
+	l.must(contract.RejectOffer(l.as(l.seller, nil), "O1"))
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"), "offer O1 is Rejected")
+	l.mustFail(contract.WithdrawOffer(l.as(l.buyer, l.offer("O1")), "O1"), "offer O1 is Rejected")
+
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O2")), "O2"))
+	l.mustFail(contract.WithdrawOffer(l.as(l.buyer, l.offer("O2")), "O2"), "only the buyer who made offer O2")
+	l.must(contract.WithdrawOffer(l.as(l.coBuyer, l.offer("O2")), "O2"))
+	if land := l.land("L1"); land.AcceptedOfferID != "" {
+		t.Fatalf("withdrawn offer %q is still accepted", land.AcceptedOfferID)
+	}
+
+	l.requestToBuy(l.buyer, "O3", "L1", nil)
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O3")), "O3"))
+}
+
+func TestOfferPresentedDataMustMatch(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.requestToBuy(l.buyer, "O1", "L1", nil)
+
+	var offer Offer
+	l.must(json.Unmarshal(l.privateJSON(collectionBuyerSeller, "O1"), &offer))
+	offer.PriceMinor = 100
+	altered, err := json.Marshal(offer)
+	l.must(err)
+	tampered := map[string][]byte{"offer": altered}
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, tampered), "O1"), "does not match the recorded offer")
+	l.mustFail(contract.AcceptOffer(l.as(l.seller, nil), "O1"), "offer key missing")
+}
+
+func TestOfferPriceIsInMinorUnits(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	land := l.land("L1")
+	land.TitleVerified = true
+	l.putLand(land)
+
+	request := func(price interface{}) map[string][]byte {
+		transient := l.buyerRequest(l.buyer, "L1", nil)
+		var fields map[string]interface{}
+		l.must(json.Unmarshal(transient["buyerRequest"], &fields))
+		fields["priceMinor"] = price
+		requestJSON, err := json.Marshal(fields)
+		l.must(err)
+		return map[string][]byte{"buyerRequest": requestJSON}
+	}
+	l.mustFail(contract.RequestToBuy(l.as(l.buyer, request(0)), "O1"), "priceMinor must be a positive amount")
+	l.mustFail(contract.RequestToBuy(l.as(l.buyer, request("1000000.00")), "O1"), "failed to parse buyer request")
+	l.must(contract.RequestToBuy(l.as(l.buyer, request(150000050)), "O1"))
+
+	l.must(contract.AcceptOffer(l.as(l.seller, l.offer("O1")), "O1"))
+	_, err := contract.RegisterToBuyer(l.as(l.registrar, map[string][]byte{
+		"acceptedOffer": l.privateJSON(collectionBuyerSeller, "O1"),
+		"documentHash":  []byte("deed"),
+	}), "L1")
+	l.must(err)
+	var record BuyerOwnership
+	l.must(json.Unmarshal(l.privateJSON(collectionBuyerLandRegistry, "L1"), &record))
+	if record.SellingPrice != "1500000.50" {
+		t.Fatalf("ownership record has selling price %q, want 1500000.50", record.SellingPrice)
+	}
+}
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const collectionBuyerLandRegistry = "collectionBuyerLandRegistry"
+
+// OwnershipVerification is the outcome of checking a presented ownership record
+// against the hash every peer holds for collectionBuyerLandRegistry
+type OwnershipVerification struct {
+	LandID     string `json:"landID"`
+	Verified   bool   `json:"verified"`
+	RecordHash string `json:"recordHash"` // hex SHA-256 of the record as presented
+	Reason     string `json:"reason,omitempty"`
+}
+
+// Anyone, such as a bank or a court, checks that a buyer's ownership record is
+// exactly what the Registry stored for a land, without reading the collection.
+// Only the record of the current transfer verifies; earlier ones were replaced.
+func (c *LandContract) VerifyOwnershipRecord(ctx contractapi.TransactionContextInterface, landID string, candidateJSON string) (*OwnershipVerification, error) {
+	if landID == "" {
+		return nil, fmt.Errorf("landID is required")
+	}
+
+	storedHash, err := ctx.GetStub().GetPrivateDataHash(collectionBuyerLandRegistry, landID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ownership record hash: %v", err)
+	}
+	if storedHash == nil {
+		return nil, fmt.Errorf("ownership record for land %s does not exist", landID)
+	}
+
+	// Records registered before the verification endpoint were stored as the raw
+	// buyerOwnership bytes, so the candidate is first checked exactly as presented
+	rawHash := sha256.Sum256([]byte(candidateJSON))
+	if bytes.Equal(rawHash[:], storedHash) {
+		return &OwnershipVerification{LandID: landID, Verified: true, RecordHash: hex.EncodeToString(rawHash[:])}, nil
+	}
+
+	// Later records are stored as json.Marshal(BuyerOwnership), so re-marshaling
+	// the candidate yields the stored bytes regardless of spacing or field order.
+	// Unknown fields are refused rather than silently dropped before hashing.
+	var candidate BuyerOwnership
+	decoder := json.NewDecoder(bytes.NewReader([]byte(candidateJSON)))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ownership record: %v", err)
+	}
+	canonical, err := json.Marshal(candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ownership record: %v", err)
+	}
+	hash := sha256.Sum256(canonical)
+
+	result := OwnershipVerification{LandID: landID, RecordHash: hex.EncodeToString(hash[:])}
+	if candidate.LandID != landID {
+		result.Reason = fmt.Sprintf("record is for land %s", candidate.LandID)
+		return &result, nil
+	}
+
+	result.Verified = bytes.Equal(hash[:], storedHash)
+	if !result.Verified {
+		result.Reason = "record does not match the registered ownership record"
+	}
+	return &result, nil
+}
+
+// presentedOwnershipRecords reads the ownership records of lands, passed as a list
+// in "ownershipRecords" in transient data (see GetOwnershipRecord), and checks
+// each against its stored hash. The owner's peers cannot read the records, so
+// every endorser verifies the same presented copy. Lands without a record need
+// none; the result maps land IDs to records.
+func presentedOwnershipRecords(ctx contractapi.TransactionContextInterface, landIDs []string) (map[string]*BuyerOwnership, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("error getting transient data: %v", err)
+	}
+	var presented []json.RawMessage
+	if recordsData, ok := transient["ownershipRecords"]; ok {
+		err = json.Unmarshal(recordsData, &presented)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ownership records: %v", err)
+		}
+	}
+	candidates := make([]*BuyerOwnership, len(presented))
+	for i, recordData := range presented {
+		err = json.Unmarshal(recordData, &candidates[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ownership records: %v", err)
+		}
+	}
+
+	records := map[string]*BuyerOwnership{}
+	for _, landID := range landIDs {
+		storedHash, err := ctx.GetStub().GetPrivateDataHash(collectionBuyerLandRegistry, landID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ownership record hash: %v", err)
+		}
+		if storedHash == nil {
+			continue
+		}
+
+		var record *BuyerOwnership
+		var recordData []byte
+		for i, candidate := range candidates {
+			if candidate != nil && candidate.LandID == landID {
+				record = candidate
+				recordData = presented[i]
+				break
+			}
+		}
+		if record == nil {
+			return nil, fmt.Errorf("ownership record of land %s missing in transient data", landID)
+		}
+		matches, err := ownershipRecordMatches(recordData, record, storedHash)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			return nil, fmt.Errorf("ownership record of land %s does not match the registered ownership record", landID)
+		}
+		records[landID] = record
+	}
+	return records, nil
+}
+
+// ownershipRecordMatches checks a presented ownership record against its stored
+// hash, first as the bytes presented, as older records were stored, then in the
+// json.Marshal form putOwnershipRecord stores
+func ownershipRecordMatches(recordData []byte, record *BuyerOwnership, storedHash []byte) (bool, error) {
+	hash := sha256.Sum256(recordData)
+	if bytes.Equal(hash[:], storedHash) {
+		return true, nil
+	}
+	canonical, err := json.Marshal(record)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal ownership record: %v", err)
+	}
+	hash = sha256.Sum256(canonical)
+	return bytes.Equal(hash[:], storedHash), nil
+}
+
+// putOwnershipRecord stores a land's ownership record in collectionBuyerLandRegistry
+func putOwnershipRecord(ctx contractapi.TransactionContextInterface, record *BuyerOwnership) error {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ownership record: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(collectionBuyerLandRegistry, record.LandID, recordJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store private ownership data: %v", err)
+	}
+	return nil
+}
+
+// Current owner or a LandRegistry officer reads the ownership record, e.g. to hand
+// it to a verifier. Must be evaluated on a peer of Org2 or Org3.
+func (c *LandContract) GetOwnershipRecord(ctx contractapi.TransactionContextInterface, landID string) (*BuyerOwnership, error) {
+	officer, err := isAuthorized(ctx, "GetOwnershipRecord")
+	if err != nil {
+		return nil, err
+	}
+	if !officer {
+		if _, err := readOwnedLand(ctx, landID); err != nil {
+			return nil, err
+		}
+	}
+
+	recordBytes, err := ctx.GetStub().GetPrivateData(collectionBuyerLandRegistry, landID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ownership record: %v", err)
+	}
+	if recordBytes == nil {
+		return nil, fmt.Errorf("ownership record for land %s does not exist", landID)
+	}
+
+	var record BuyerOwnership
+	err = json.Unmarshal(recordBytes, &record)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling ownership record: %v", err)
+	}
+
+	return &record, nil
+}
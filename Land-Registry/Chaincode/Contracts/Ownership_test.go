@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// legacyRecord is an ownership record as baseline RegisterToBuyer stored it: the
+// buyerOwnership bytes exactly as the client sent them
+const legacyRecord = `{"landID": "L1", "ownerID": "buyer", "buyerName": "Buyer", "aadhar": "222233334444", "sellingPrice": "1000000.00"}`
+
+func TestVerifyOwnershipRecord(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.must(l.as(l.registrar, nil).GetStub().PutPrivateData(collectionBuyerLandRegistry, "L1", []byte(legacyRecord)))
+	record := &BuyerOwnership{OwnerID: "buyer", BuyerName: "Buyer", Aadhar: "222233334444", LandID: "L2", SellingPrice: "1000000.00"}
+	l.must(putOwnershipRecord(l.as(l.registrar, nil), record))
+
+	tests := []struct {
+		name       string
+		landID     string
+		candidate  string
+		wantReason string
+	}{
+		{name: "legacy record as stored", landID: "L1", candidate: legacyRecord},
+		{name: "legacy record reformatted", landID: "L1", candidate: `{"ownerID":"buyer","buyerName":"Buyer","aadhar":"222233334444","landID":"L1","sellingPrice":"1000000.00"}`, wantReason: "does not match"},
+		{name: "record in another field order", landID: "L2", candidate: `{"landID": "L2", "sellingPrice": "1000000.00", "ownerID": "buyer", "buyerName": "Buyer", "aadhar": "222233334444"}`},
+		{name: "altered record", landID: "L2", candidate: `{"landID":"L2","ownerID":"buyer","buyerName":"Buyer","aadhar":"222233334444","sellingPrice":"1.00"}`, wantReason: "does not match"},
+		{name: "record of another land", landID: "L2", candidate: legacyRecord, wantReason: "record is for land L1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := contract.VerifyOwnershipRecord(l.as(l.buyer, nil), test.landID, test.candidate)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Verified != (test.wantReason == "") || !strings.Contains(result.Reason, test.wantReason) {
+				t.Fatalf("got %+v, want verified %v with reason %q", result, test.wantReason == "", test.wantReason)
+			}
+		})
+	}
+
+	_, err := contract.VerifyOwnershipRecord(l.as(l.buyer, nil), "L3", legacyRecord)
+	l.mustFail(err, "ownership record for land L3 does not exist")
+}
+
+func TestPresentedLegacyOwnershipRecord(t *testing.T) {
+	l := newTestLedger(t)
+	l.must(l.as(l.registrar, nil).GetStub().PutPrivateData(collectionBuyerLandRegistry, "L1", []byte(legacyRecord)))
+
+	records, err := presentedOwnershipRecords(l.as(l.registrar, map[string][]byte{"ownershipRecords": []byte("[" + legacyRecord + "]")}), []string{"L1"})
+	l.must(err)
+	if record := records["L1"]; record == nil || record.OwnerID != "buyer" || record.SellingPrice != "1000000.00" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	var reformatted BuyerOwnership
+	l.must(json.Unmarshal([]byte(legacyRecord), &reformatted))
+	presented, err := json.Marshal([]BuyerOwnership{reformatted})
+	l.must(err)
+	_, err = presentedOwnershipRecords(l.as(l.registrar, map[string][]byte{"ownershipRecords": presented}), []string{"L1"})
+	l.mustFail(err, "ownership record of land L1 does not match the registered ownership record")
+}
@@ -0,0 +1,624 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// areaTolerance is how far, in square metres, child areas may differ from their
+// parent's after each was rounded to four decimal places
+const areaTolerance = 0.01
+
+// ParcelPart describes one child parcel of a split. Location and coordinates
+// default to the parent's.
+type ParcelPart struct {
+	LandID      string  `json:"landID"`
+	Area        float64 `json:"area"`
+	AreaUnit    string  `json:"areaUnit"`
+	Location    string  `json:"location"`
+	Coordinates string  `json:"coordinates"`
+}
+
+// LineageEntry is one ancestor of a parcel. Generation 1 is a direct parent.
+type LineageEntry struct {
+	Generation int   `json:"generation"`
+	Land       *Land `json:"land"`
+}
+
+// Parcel request kinds and states
+const (
+	ParcelSplit = "Split"
+	ParcelMerge = "Merge"
+
+	ParcelRequestPending   = "Pending"
+	ParcelRequestApproved  = "Approved"
+	ParcelRequestCancelled = "Cancelled"
+)
+
+// ParcelRequest is an owner's request to split or merge parcels, kept on the
+// public ledger until a registrar approves it with SplitLand or MergeLands
+type ParcelRequest struct {
+	RequestID   string       `json:"requestID"`
+	Kind        string       `json:"kind"`               // Split, Merge
+	LandIDs     []string     `json:"landIDs"`            // the parent of a split, or the lands to merge
+	Children    []ParcelPart `json:"children,omitempty"` // the new parcels of a split
+	NewID       string       `json:"newID,omitempty"`    // the new parcel of a merge
+	Requester   string       `json:"requester"`          // client identity ID of the owner
+	Status      string       `json:"status"`             // Pending, Approved, Cancelled
+	RequestedAt string       `json:"requestedAt"`
+	DecidedAt   string       `json:"decidedAt,omitempty"`
+}
+
+// Current owner asks the Land Registry to subdivide a parcel, e.g. for a partial
+// sale. childrenJSON is a list of ParcelPart whose areas must add up to the
+// parent's. Nothing changes until a registrar approves it with SplitLand.
+func (c *LandContract) RequestSplit(ctx contractapi.TransactionContextInterface, requestID string, parentID string, childrenJSON string) error {
+	var parts []ParcelPart
+	err := json.Unmarshal([]byte(childrenJSON), &parts)
+	if err != nil {
+		return fmt.Errorf("failed to parse children: %v", err)
+	}
+
+	parent, err := readOwnedLand(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	_, _, err = planSplit(ctx, parent, parts)
+	if err != nil {
+		return err
+	}
+
+	return putNewParcelRequest(ctx, &ParcelRequest{
+		RequestID: requestID,
+		Kind:      ParcelSplit,
+		LandIDs:   []string{parentID},
+		Children:  parts,
+		Requester: parent.Owner,
+	})
+}
+
+// Current owner asks the Land Registry to amalgamate parcels they own into a new
+// parcel. landIDsJSON is a list of at least two land IDs. Nothing changes until a
+// registrar approves it with MergeLands.
+func (c *LandContract) RequestMerge(ctx contractapi.TransactionContextInterface, requestID string, landIDsJSON string, newID string) error {
+	var landIDs []string
+	err := json.Unmarshal([]byte(landIDsJSON), &landIDs)
+	if err != nil {
+		return fmt.Errorf("failed to parse landIDs: %v", err)
+	}
+
+	_, sources, err := planMerge(ctx, landIDs, newID)
+	if err != nil {
+		return err
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if sources[0].Owner != clientID {
+		return fmt.Errorf("only the current owner can request a merge of land %s", sources[0].LandID)
+	}
+
+	return putNewParcelRequest(ctx, &ParcelRequest{
+		RequestID: requestID,
+		Kind:      ParcelMerge,
+		LandIDs:   landIDs,
+		NewID:     newID,
+		Requester: clientID,
+	})
+}
+
+// Requesting owner withdraws a pending split or merge request, or a Land
+// Registry registrar refuses it
+func (c *LandContract) CancelParcelRequest(ctx contractapi.TransactionContextInterface, requestID string) error {
+	request, err := readParcelRequest(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if request.Requester != clientID {
+		err = authorize(ctx, "CancelParcelRequest")
+		if err != nil {
+			return err
+		}
+	}
+	if request.Status != ParcelRequestPending {
+		return fmt.Errorf("parcel request %s is %s", requestID, strings.ToLower(request.Status))
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	request.Status = ParcelRequestCancelled
+	request.DecidedAt = now.Format(time.RFC3339)
+	err = putParcelRequest(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventParcelRequestCancelled, LandEvent{LandIDs: request.LandIDs, RequestID: requestID})
+}
+
+// Anyone reads a split or merge request
+func (c *LandContract) GetParcelRequest(ctx contractapi.TransactionContextInterface, requestID string) (*ParcelRequest, error) {
+	return readParcelRequest(ctx, requestID)
+}
+
+// Land Registry (Org3) approves an owner's split request. The children belong to
+// the parent's owner and are off the market; the parent is retired and keeps
+// links to them. If the parent has an ownership record, the registrar presents
+// it (see presentedOwnershipRecords) and each child gets a copy for its own area.
+func (c *LandContract) SplitLand(ctx contractapi.TransactionContextInterface, requestID string) error {
+	err := authorize(ctx, "SplitLand")
+	if err != nil {
+		return err
+	}
+
+	request, err := readPendingParcelRequest(ctx, requestID, ParcelSplit)
+	if err != nil {
+		return err
+	}
+	parentID := request.LandIDs[0]
+	parent, err := readLand(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent.Owner != request.Requester {
+		return fmt.Errorf("land with ID %s has changed owner since request %s", parentID, requestID)
+	}
+	children, childIDs, err := planSplit(ctx, parent, request.Children)
+	if err != nil {
+		return err
+	}
+	records, err := presentedOwnershipRecords(ctx, request.LandIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		err = putLand(ctx, child)
+		if err != nil {
+			return err
+		}
+		err = setLandEndorsement(ctx, child)
+		if err != nil {
+			return err
+		}
+		if record, ok := records[parentID]; ok {
+			err = putOwnershipRecord(ctx, parcelOwnershipRecord(record, child))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	parent.Status = StatusRetired
+	parent.ChildIDs = childIDs
+	err = putLand(ctx, parent)
+	if err != nil {
+		return err
+	}
+	err = approveParcelRequest(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(parent)
+	event.LandIDs = childIDs
+	event.RequestID = requestID
+	return emitEvent(ctx, EventLandSplit, event)
+}
+
+// Land Registry (Org3) approves an owner's merge request. The new parcel takes
+// its description and area unit from the first land, and its area is the sum;
+// the merged parcels are retired and link to it. Like SplitLand it carries the
+// ownership record over, taken from the first land that has one.
+func (c *LandContract) MergeLands(ctx contractapi.TransactionContextInterface, requestID string) error {
+	err := authorize(ctx, "MergeLands")
+	if err != nil {
+		return err
+	}
+
+	request, err := readPendingParcelRequest(ctx, requestID, ParcelMerge)
+	if err != nil {
+		return err
+	}
+	merged, sources, err := planMerge(ctx, request.LandIDs, request.NewID)
+	if err != nil {
+		return err
+	}
+	if merged.Owner != request.Requester {
+		return fmt.Errorf("land with ID %s has changed owner since request %s", sources[0].LandID, requestID)
+	}
+	records, err := presentedOwnershipRecords(ctx, request.LandIDs)
+	if err != nil {
+		return err
+	}
+
+	err = putLand(ctx, merged)
+	if err != nil {
+		return err
+	}
+	err = setLandEndorsement(ctx, merged)
+	if err != nil {
+		return err
+	}
+	for _, landID := range request.LandIDs {
+		if record, ok := records[landID]; ok {
+			err = putOwnershipRecord(ctx, parcelOwnershipRecord(record, merged))
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	for _, land := range sources {
+		land.Status = StatusRetired
+		land.ChildIDs = []string{merged.LandID}
+		err = putLand(ctx, land)
+		if err != nil {
+			return err
+		}
+	}
+	err = approveParcelRequest(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(merged)
+	event.LandIDs = request.LandIDs
+	event.RequestID = requestID
+	return emitEvent(ctx, EventLandsMerged, event)
+}
+
+// planSplit checks a parent can be split into parts and builds the children
+func planSplit(ctx contractapi.TransactionContextInterface, parent *Land, parts []ParcelPart) ([]*Land, []string, error) {
+	if len(parts) < 2 {
+		return nil, nil, fmt.Errorf("a split must produce at least two children")
+	}
+	parentID := parent.LandID
+	err := requireOffMarket(parent)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = requireNoActiveLiens(ctx, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = requireNotFrozen(ctx, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = requireNotLeased(ctx, parent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{parentID: true}
+	children := make([]*Land, 0, len(parts))
+	childIDs := make([]string, 0, len(parts))
+	var totalSqm float64
+	for _, part := range parts {
+		if part.LandID == "" {
+			return nil, nil, fmt.Errorf("landID is required for every child")
+		}
+		if seen[part.LandID] {
+			return nil, nil, fmt.Errorf("land ID %s is used more than once in the split", part.LandID)
+		}
+		seen[part.LandID] = true
+		err = requireNewLandID(ctx, part.LandID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		child := *parent
+		child.LandID = part.LandID
+		child.PriceMinor = 0
+		child.AcceptedOfferID = ""
+		child.AcceptedExpiry = ""
+		child.AuctionID = ""
+		child.LeaseID = ""
+		child.LeasedUntil = ""
+		child.ParentIDs = []string{parentID}
+		child.ChildIDs = nil
+		if part.Location != "" {
+			child.Location = part.Location
+		}
+		child.Coordinates = part.Coordinates
+		if part.Coordinates == "" {
+			child.Coordinates = parent.Coordinates
+		}
+		err = setLandArea(&child, part.Area, part.AreaUnit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("child %s: %v", part.LandID, err)
+		}
+
+		totalSqm += child.AreaSqm
+		children = append(children, &child)
+		childIDs = append(childIDs, child.LandID)
+	}
+	if math.Abs(totalSqm-parent.AreaSqm) > areaTolerance {
+		return nil, nil, fmt.Errorf("child areas add up to %.4f sqm but land %s is %.4f sqm", totalSqm, parentID, parent.AreaSqm)
+	}
+
+	return children, childIDs, nil
+}
+
+// planMerge checks lands can be merged under newID and builds the new parcel
+func planMerge(ctx contractapi.TransactionContextInterface, landIDs []string, newID string) (*Land, []*Land, error) {
+	if len(landIDs) < 2 {
+		return nil, nil, fmt.Errorf("a merge needs at least two lands")
+	}
+	if newID == "" {
+		return nil, nil, fmt.Errorf("newID is required")
+	}
+	err := requireNewLandID(ctx, newID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	sources := make([]*Land, 0, len(landIDs))
+	var coordinates []string
+	var totalSqm float64
+	titleVerified := true
+	for _, landID := range landIDs {
+		if seen[landID] {
+			return nil, nil, fmt.Errorf("land ID %s is used more than once in the merge", landID)
+		}
+		seen[landID] = true
+
+		land, err := readLand(ctx, landID)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = requireOffMarket(land)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = requireNoActiveLiens(ctx, landID)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = requireNotFrozen(ctx, landID)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = requireNotLeased(ctx, land)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(sources) > 0 && (land.Owner != sources[0].Owner || land.OwnerMSP != sources[0].OwnerMSP || !sameShares(land.Shares, sources[0].Shares)) {
+			return nil, nil, fmt.Errorf("lands %s and %s have different owners", sources[0].LandID, landID)
+		}
+
+		sources = append(sources, land)
+		totalSqm += land.AreaSqm
+		titleVerified = titleVerified && land.TitleVerified
+		if land.Coordinates != "" && !contains(coordinates, land.Coordinates) {
+			coordinates = append(coordinates, land.Coordinates)
+		}
+	}
+
+	first := sources[0]
+	unitSqm, ok := sqmPerUnit[first.AreaUnit]
+	if !ok {
+		return nil, nil, fmt.Errorf("land with ID %s has no area unit; migrate it first", first.LandID)
+	}
+	merged := *first
+	merged.LandID = newID
+	merged.AreaSqm = math.Round(totalSqm*10000) / 10000
+	merged.Area = math.Round(totalSqm/unitSqm*10000) / 10000
+	merged.Coordinates = strings.Join(coordinates, "; ")
+	merged.PriceMinor = 0
+	merged.AcceptedOfferID = ""
+	merged.AcceptedExpiry = ""
+	merged.AuctionID = ""
+	merged.LeaseID = ""
+	merged.LeasedUntil = ""
+	merged.TitleVerified = titleVerified
+	merged.ParentIDs = landIDs
+	merged.ChildIDs = nil
+
+	return &merged, sources, nil
+}
+
+// parcelOwnershipRecord is a copy of an ownership record for a new parcel made
+// from the land it was issued for
+func parcelOwnershipRecord(record *BuyerOwnership, land *Land) *BuyerOwnership {
+	copied := *record
+	copied.LandID = land.LandID
+	copied.Location = land.Location
+	copied.Size = formatArea(land)
+	copied.Coordinates = land.Coordinates
+	return &copied
+}
+
+// Anyone walks a parcel's ancestry through splits and merges, nearest ancestors first
+func (c *LandContract) GetLandLineage(ctx contractapi.TransactionContextInterface, landID string) ([]*LineageEntry, error) {
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := []*LineageEntry{}
+	visited := map[string]bool{landID: true}
+	generation := land.ParentIDs
+	for depth := 1; len(generation) > 0; depth++ {
+		var next []string
+		for _, parentID := range generation {
+			if visited[parentID] {
+				continue
+			}
+			visited[parentID] = true
+
+			parent, err := readLand(ctx, parentID)
+			if err != nil {
+				return nil, err
+			}
+			lineage = append(lineage, &LineageEntry{Generation: depth, Land: parent})
+			next = append(next, parent.ParentIDs...)
+		}
+		generation = next
+	}
+
+	return lineage, nil
+}
+
+// requireOffMarket checks a land can be split or merged: owned, not retired and
+// not for sale, in auction or awaiting a transfer or succession
+func requireOffMarket(land *Land) error {
+	switch land.Status {
+	case StatusRetired:
+		return fmt.Errorf("land with ID %s has been retired", land.LandID)
+	case StatusForSale:
+		return fmt.Errorf("land with ID %s is listed for sale", land.LandID)
+	case StatusInAuction:
+		return fmt.Errorf("land with ID %s is in auction", land.LandID)
+	}
+	if land.AcceptedOfferID != "" {
+		return fmt.Errorf("land with ID %s has an accepted offer", land.LandID)
+	}
+	if land.SuccessionID != "" {
+		return fmt.Errorf("land with ID %s has succession %s pending", land.LandID, land.SuccessionID)
+	}
+	return nil
+}
+
+// sameShares reports whether two lands are held in the same shares
+func sameShares(a []OwnerShare, b []OwnerShare) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// putNewParcelRequest stores a new pending split or merge request and announces it
+func putNewParcelRequest(ctx contractapi.TransactionContextInterface, request *ParcelRequest) error {
+	if request.RequestID == "" {
+		return fmt.Errorf("requestID is required")
+	}
+	key, err := parcelRequestKey(ctx, request.RequestID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read parcel request: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("parcel request %s already exists", request.RequestID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	request.Status = ParcelRequestPending
+	request.RequestedAt = now.Format(time.RFC3339)
+	err = putParcelRequest(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventParcelChangeRequested, LandEvent{LandIDs: request.LandIDs, RequestID: request.RequestID})
+}
+
+// readPendingParcelRequest loads a request of the given kind that still awaits a decision
+func readPendingParcelRequest(ctx contractapi.TransactionContextInterface, requestID string, kind string) (*ParcelRequest, error) {
+	request, err := readParcelRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Kind != kind {
+		return nil, fmt.Errorf("parcel request %s is not a %s request", requestID, strings.ToLower(kind))
+	}
+	if request.Status != ParcelRequestPending {
+		return nil, fmt.Errorf("parcel request %s is %s", requestID, strings.ToLower(request.Status))
+	}
+	return request, nil
+}
+
+// approveParcelRequest marks a request as carried out
+func approveParcelRequest(ctx contractapi.TransactionContextInterface, request *ParcelRequest) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	request.Status = ParcelRequestApproved
+	request.DecidedAt = now.Format(time.RFC3339)
+	return putParcelRequest(ctx, request)
+}
+
+func readParcelRequest(ctx contractapi.TransactionContextInterface, requestID string) (*ParcelRequest, error) {
+	key, err := parcelRequestKey(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	requestJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parcel request: %v", err)
+	}
+	if requestJSON == nil {
+		return nil, fmt.Errorf("parcel request %s does not exist", requestID)
+	}
+
+	var request ParcelRequest
+	err = json.Unmarshal(requestJSON, &request)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling parcel request: %v", err)
+	}
+	return &request, nil
+}
+
+func putParcelRequest(ctx contractapi.TransactionContextInterface, request *ParcelRequest) error {
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parcel request: %v", err)
+	}
+	key, err := parcelRequestKey(ctx, request.RequestID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, requestJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store parcel request: %v", err)
+	}
+	return nil
+}
+
+func parcelRequestKey(ctx contractapi.TransactionContextInterface, requestID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("parcelrequest", []string{requestID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create parcel request key: %v", err)
+	}
+	return key, nil
+}
+
+// requireNewLandID checks no land is stored under an ID yet
+func requireNewLandID(ctx contractapi.TransactionContextInterface, landID string) error {
+	existing, err := ctx.GetStub().GetState(landID)
+	if err != nil {
+		return fmt.Errorf("failed to read land from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("land with ID %s already exists", landID)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// ownershipRecords stores an ownership record for each land and returns them as
+// transient data, as a registrar presents them
+func (l *testLedger) ownershipRecords(landIDs ...string) map[string][]byte {
+	l.t.Helper()
+	var records []*BuyerOwnership
+	for _, landID := range landIDs {
+		record := &BuyerOwnership{OwnerID: "seller", BuyerName: "Seller", Aadhar: "111122223333", LandID: landID, DocumentHash: "sale"}
+		l.must(putOwnershipRecord(l.as(l.registrar, nil), record))
+		records = append(records, record)
+	}
+	presented, err := json.Marshal(records)
+	l.must(err)
+	return map[string][]byte{"ownershipRecords": presented}
+}
+
+func TestSplitLand(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.mustFail(contract.RequestSplit(l.as(l.seller, nil), "P1", "L1", `[{"landID":"L1A","area":0.5,"areaUnit":"acre"},{"landID":"L1B","area":0.5,"areaUnit":"acre"}]`), "land with ID L1 is listed for sale")
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L1"))
+	records := l.ownershipRecords("L1")
+
+	// 1 acre is 4046.8564 sqm; children may be off by at most areaTolerance
+	l.mustFail(contract.RequestSplit(l.as(l.seller, nil), "P1", "L1", `[{"landID":"L1A","area":2023.45,"areaUnit":"sqm"},{"landID":"L1B","area":2023.42,"areaUnit":"sqm"}]`),
+		"child areas add up to 4046.8700 sqm but land L1 is 4046.8564 sqm")
+	l.mustFail(contract.RequestSplit(l.as(l.seller, nil), "P1", "L1", `[{"landID":"L1A","area":4046.8564,"areaUnit":"sqm"}]`), "at least two children")
+	children := `[{"landID":"L1A","area":2023.43,"areaUnit":"sqm","location":"North plot"},{"landID":"L1B","area":2023.43,"areaUnit":"sqm"}]`
+	l.mustFail(contract.RequestSplit(l.as(l.buyer, nil), "P1", "L1", children), "only the current owner can modify land L1")
+	l.must(contract.RequestSplit(l.as(l.seller, nil), "P1", "L1", children))
+	if land := l.land("L1"); land.Status != StatusDelisted || len(land.ChildIDs) != 0 {
+		t.Fatalf("request alone changed land to %s with children %v", land.Status, land.ChildIDs)
+	}
+
+	l.mustFail(contract.SplitLand(l.as(l.seller, records), "P1"), "a LandRegistry registrar can split land")
+	l.mustFail(contract.SplitLand(l.as(l.registrar, nil), "P1"), "ownership record of land L1 missing in transient data")
+
+	parent := l.land("L1")
+	sold := *parent
+	sold.Owner = "buyer"
+	sold.OwnerMSP = "Org2MSP"
+	l.putLand(&sold)
+	l.mustFail(contract.SplitLand(l.as(l.registrar, records), "P1"), "land with ID L1 has changed owner since request P1")
+	l.putLand(parent)
+
+	l.must(contract.SplitLand(l.as(l.registrar, records), "P1"))
+	l.mustFail(contract.SplitLand(l.as(l.registrar, records), "P1"), "parcel request P1 is approved")
+
+	parent = l.land("L1")
+	if parent.Status != StatusRetired || !reflect.DeepEqual(parent.ChildIDs, []string{"L1A", "L1B"}) {
+		t.Fatalf("parent is %s with children %v after the split", parent.Status, parent.ChildIDs)
+	}
+	child := l.land("L1A")
+	if child.Owner != "seller" || child.Status != StatusDelisted || child.AreaSqm != 2023.43 || child.Location != "North plot" ||
+		!reflect.DeepEqual(child.ParentIDs, []string{"L1"}) || child.PriceMinor != 0 {
+		t.Fatalf("unexpected child after the split: %+v", child)
+	}
+	if sibling := l.land("L1B"); sibling.Location != parent.Location {
+		t.Fatalf("child without a location is at %q, want the parent's %q", sibling.Location, parent.Location)
+	}
+
+	var record BuyerOwnership
+	l.must(json.Unmarshal(l.privateJSON(collectionBuyerLandRegistry, "L1A"), &record))
+	if record.LandID != "L1A" || record.OwnerID != "seller" || record.DocumentHash != "sale" || record.Size != "2023.43 sqm" {
+		t.Fatalf("unexpected ownership record for the child: %+v", record)
+	}
+
+	lineage, err := contract.GetLandLineage(l.as(l.registrar, nil), "L1B")
+	l.must(err)
+	if len(lineage) != 1 || lineage[0].Generation != 1 || lineage[0].Land.LandID != "L1" {
+		t.Fatalf("unexpected lineage of the child: %+v", lineage)
+	}
+	l.mustFail(contract.RequestSplit(l.as(l.seller, nil), "P2", "L1", children), "land with ID L1 has been retired")
+}
+
+func TestMergeLands(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	for _, landID := range []string{"L1", "L2"} {
+		l.listLand(landID)
+		l.must(contract.DelistLand(l.as(l.seller, nil), landID))
+	}
+	l.jointLand("L3", 5000)
+
+	l.mustFail(contract.RequestMerge(l.as(l.seller, nil), "P1", `["L1","L3"]`, "M1"), "lands L1 and L3 have different owners")
+	l.mustFail(contract.RequestMerge(l.as(l.seller, nil), "P1", `["L1","L1"]`, "M1"), "land ID L1 is used more than once in the merge")
+	l.mustFail(contract.RequestMerge(l.as(l.buyer, nil), "P1", `["L1","L2"]`, "M1"), "only the current owner can request a merge of land L1")
+	l.must(contract.RequestMerge(l.as(l.seller, nil), "P1", `["L1","L2"]`, "M1"))
+
+	records := l.ownershipRecords("L2")
+	l.mustFail(contract.MergeLands(l.as(l.registrar, nil), "P1"), "ownership record of land L2 missing in transient data")
+	l.must(contract.MergeLands(l.as(l.registrar, records), "P1"))
+
+	merged := l.land("M1")
+	if merged.Owner != "seller" || merged.AreaSqm != 8093.7128 || merged.Area != 2 || merged.AreaUnit != UnitAcre ||
+		!reflect.DeepEqual(merged.ParentIDs, []string{"L1", "L2"}) {
+		t.Fatalf("unexpected merged land: %+v", merged)
+	}
+	for _, landID := range []string{"L1", "L2"} {
+		if land := l.land(landID); land.Status != StatusRetired || !reflect.DeepEqual(land.ChildIDs, []string{"M1"}) {
+			t.Fatalf("land %s is %s with children %v after the merge", landID, land.Status, land.ChildIDs)
+		}
+	}
+
+	var record BuyerOwnership
+	l.must(json.Unmarshal(l.privateJSON(collectionBuyerLandRegistry, "M1"), &record))
+	if record.LandID != "M1" || record.Size != "2 acre" {
+		t.Fatalf("unexpected ownership record for the merged land: %+v", record)
+	}
+
+	lineage, err := contract.GetLandLineage(l.as(l.registrar, nil), "M1")
+	l.must(err)
+	if len(lineage) != 2 || lineage[0].Land.LandID != "L1" || lineage[1].Land.LandID != "L2" || lineage[1].Generation != 1 {
+		t.Fatalf("unexpected lineage of the merged land: %+v", lineage)
+	}
+}
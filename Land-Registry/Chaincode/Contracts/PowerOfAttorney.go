@@ -0,0 +1,445 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	GrantActive  = "Active"
+	GrantRevoked = "Revoked"
+	GrantExpired = "Expired"
+)
+
+// Scopes of a power of attorney
+const (
+	ScopeList     = "list"     // list, re-price, delist and auction the land
+	ScopeAccept   = "accept"   // accept and reject offers
+	ScopeLease    = "lease"    // offer a lease on the land
+	ScopeTransfer = "transfer" // consent to a sale and transfer a share
+	ScopeBuy      = "buy"      // make and withdraw offers to buy the land
+)
+
+// attorneyScopes maps each transaction an agent may act in to the scope of power
+// it needs. A grant only stands in for the owner or holder check; where the
+// transaction has an access rule (ManageLand for owner actions), the agent must
+// pass it with their own identity.
+var attorneyScopes = map[string]string{
+	"ListLand":           ScopeList,
+	"DelistLand":         ScopeList,
+	"UpdateSellingPrice": ScopeList,
+	"SubmitSellerTitle":  ScopeList,
+	"StartAuction":       ScopeList,
+	"CloseAuction":       ScopeList,
+	"AcceptOffer":        ScopeAccept,
+	"RejectOffer":        ScopeAccept,
+	"RegisterLease":      ScopeLease,
+	"ConsentToSale":      ScopeTransfer,
+	"TransferShare":      ScopeTransfer,
+	"RequestToBuy":       ScopeBuy,
+	"WithdrawOffer":      ScopeBuy,
+}
+
+// PowerOfAttorney lets an agent act for a principal on one land, within its
+// scopes and until it expires or is revoked. Grants are public so anyone can
+// check an agent's authority.
+type PowerOfAttorney struct {
+	GrantID      string   `json:"grantID"`
+	LandID       string   `json:"landID"`
+	Principal    string   `json:"principal"` // client identity ID of the grantor
+	PrincipalMSP string   `json:"principalMSP"`
+	Agent        string   `json:"agent"` // client identity ID of the attorney
+	AgentMSP     string   `json:"agentMSP"`
+	Scopes       []string `json:"scopes"`
+	GrantedAt    string   `json:"grantedAt"`
+	Expiry       string   `json:"expiry"` // RFC3339
+	Status       string   `json:"status"` // Active, Revoked, Expired
+	RevokedAt    string   `json:"revokedAt,omitempty"`
+}
+
+// AgentAction records that an agent acted for a principal under a grant
+type AgentAction struct {
+	LandID    string `json:"landID"`
+	GrantID   string `json:"grantID"`
+	Agent     string `json:"agent"`
+	Principal string `json:"principal"`
+	Action    string `json:"action"` // the transaction name
+	TxID      string `json:"txID"`
+	ActedAt   string `json:"actedAt"`
+}
+
+// Owner, holder or buyer gives another identity power of attorney over a land.
+// scopesJSON is a list of "list", "accept", "lease", "transfer" and "buy"; the
+// first three need the owner, "transfer" a holder, and "buy" a Buyer who does not hold the
+// land. expiry is RFC3339.
+func (c *LandContract) GrantPowerOfAttorney(ctx contractapi.TransactionContextInterface, landID string, grantID string, agentID string, agentMSP string, scopesJSON string, expiry string) error {
+	if grantID == "" || agentID == "" || agentMSP == "" {
+		return fmt.Errorf("grantID, agentID and agentMSP are required")
+	}
+
+	var scopes []string
+	err := json.Unmarshal([]byte(scopesJSON), &scopes)
+	if err != nil {
+		return fmt.Errorf("failed to parse scopes: %v", err)
+	}
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		return fmt.Errorf("expiry must be an RFC3339 timestamp: %v", err)
+	}
+	if !expiresAt.After(now) {
+		return fmt.Errorf("grant expiry %s is already in the past", expiry)
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if land.Status == StatusRetired {
+		return fmt.Errorf("land with ID %s has been retired", landID)
+	}
+
+	principal, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	principalMSP, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if principal == agentID {
+		return fmt.Errorf("a principal cannot grant power of attorney to themselves")
+	}
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	err = config.requireMSP(agentMSP, PartySeller, PartyBuyer)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if seen[scope] {
+			return fmt.Errorf("scope %s is listed more than once", scope)
+		}
+		seen[scope] = true
+
+		switch scope {
+		case ScopeList, ScopeAccept, ScopeLease:
+			if land.Owner != principal {
+				return fmt.Errorf("only the current owner can grant %s power over land %s", scope, landID)
+			}
+		case ScopeTransfer:
+			if holderShare(land, principal) == 0 {
+				return fmt.Errorf("only a holder can grant %s power over land %s", scope, landID)
+			}
+		case ScopeBuy:
+			allowed, err := isAuthorized(ctx, "RequestToBuy")
+			if err != nil {
+				return err
+			}
+			if !allowed || holderShare(land, principal) > 0 {
+				return fmt.Errorf("only a Buyer who does not hold land %s can grant %s power over it", landID, scope)
+			}
+		default:
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	key, err := grantKey(ctx, landID, grantID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read power of attorney: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("power of attorney with ID %s already exists on land %s", grantID, landID)
+	}
+
+	grant := PowerOfAttorney{
+		GrantID:      grantID,
+		LandID:       landID,
+		Principal:    principal,
+		PrincipalMSP: principalMSP,
+		Agent:        agentID,
+		AgentMSP:     agentMSP,
+		Scopes:       scopes,
+		GrantedAt:    now.Format(time.RFC3339),
+		Expiry:       expiresAt.UTC().Format(time.RFC3339),
+		Status:       GrantActive,
+	}
+	err = putGrant(ctx, &grant)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventPowerOfAttorneyGranted, LandEvent{LandID: landID, GrantID: grantID, Agent: agentID})
+}
+
+// Principal revokes a power of attorney they granted
+func (c *LandContract) RevokePowerOfAttorney(ctx contractapi.TransactionContextInterface, landID string, grantID string) error {
+	grant, err := readGrant(ctx, landID, grantID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if grant.Principal != clientID {
+		return fmt.Errorf("only the principal can revoke power of attorney %s", grantID)
+	}
+	if grant.Status != GrantActive {
+		return fmt.Errorf("power of attorney %s is already revoked", grantID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	grant.Status = GrantRevoked
+	grant.RevokedAt = now.Format(time.RFC3339)
+	err = putGrant(ctx, grant)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventPowerOfAttorneyRevoked, LandEvent{LandID: landID, GrantID: grantID, Agent: grant.Agent})
+}
+
+// Anyone lists the powers of attorney granted over a land. A lapsed grant is
+// reported as Expired.
+func (c *LandContract) GetPowersOfAttorney(ctx contractapi.TransactionContextInterface, landID string) ([]*PowerOfAttorney, error) {
+	if _, err := readLand(ctx, landID); err != nil {
+		return nil, err
+	}
+
+	grants, err := readGrants(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if grant.Status == GrantActive && grantExpired(grant, now) {
+			grant.Status = GrantExpired
+		}
+	}
+	return grants, nil
+}
+
+// Anyone lists what agents have done on a land under power of attorney
+func (c *LandContract) GetAgentActions(ctx contractapi.TransactionContextInterface, landID string) ([]*AgentAction, error) {
+	if _, err := readLand(ctx, landID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("agentaction", []string{landID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent actions: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	actions := []*AgentAction{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var action AgentAction
+		err = json.Unmarshal(queryResponse.Value, &action)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling agent action: %v", err)
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
+
+// readManagedLand loads a land record for its current owner, or for an agent
+// acting for the owner in txName. It returns the agent's ID, empty when the
+// owner acts.
+func readManagedLand(ctx contractapi.TransactionContextInterface, txName string, landID string) (*Land, string, error) {
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if land.Owner == clientID {
+		return land, "", nil
+	}
+
+	grant, err := actingAgent(ctx, txName, landID, land.Owner)
+	if err != nil {
+		return nil, "", err
+	}
+	if grant == nil {
+		return nil, "", fmt.Errorf("only the current owner or their attorney can modify land %s", landID)
+	}
+	return land, grant.Agent, nil
+}
+
+// actingAgent finds the caller's power of attorney from principal covering
+// txName on a land and records that the agent acted. It returns nil when the
+// caller holds no such grant.
+func actingAgent(ctx contractapi.TransactionContextInterface, txName string, landID string, principal string) (*PowerOfAttorney, error) {
+	agent, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grant, err := activeGrant(ctx, landID, principal, agent, attorneyScopes[txName])
+	if err != nil || grant == nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txID := ctx.GetStub().GetTxID()
+	action := AgentAction{
+		LandID:    landID,
+		GrantID:   grant.GrantID,
+		Agent:     agent,
+		Principal: principal,
+		Action:    txName,
+		TxID:      txID,
+		ActedAt:   now.Format(time.RFC3339),
+	}
+	actionJSON, err := json.Marshal(action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal agent action: %v", err)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey("agentaction", []string{landID, txID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent action key: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, actionJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store agent action: %v", err)
+	}
+
+	return grant, nil
+}
+
+// activeGrant returns an unexpired, unrevoked grant from principal to agent over
+// a land that covers scope, or nil
+func activeGrant(ctx contractapi.TransactionContextInterface, landID string, principal string, agent string, scope string) (*PowerOfAttorney, error) {
+	if scope == "" {
+		return nil, nil
+	}
+	grants, err := readGrants(ctx, landID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if grant.Principal == principal && grant.Agent == agent && grant.Status == GrantActive &&
+			!grantExpired(grant, now) && contains(grant.Scopes, scope) {
+			return grant, nil
+		}
+	}
+	return nil, nil
+}
+
+// grantExpired is judged against the transaction time; an unreadable expiry
+// counts as expired
+func grantExpired(grant *PowerOfAttorney, now time.Time) bool {
+	expiry, err := time.Parse(time.RFC3339, grant.Expiry)
+	return err != nil || !now.Before(expiry)
+}
+
+// readGrants returns every power of attorney granted over a land
+func readGrants(ctx contractapi.TransactionContextInterface, landID string) ([]*PowerOfAttorney, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("poa", []string{landID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read powers of attorney: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	grants := []*PowerOfAttorney{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var grant PowerOfAttorney
+		err = json.Unmarshal(queryResponse.Value, &grant)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling power of attorney: %v", err)
+		}
+		grants = append(grants, &grant)
+	}
+	return grants, nil
+}
+
+// readGrant loads one power of attorney from the public ledger
+func readGrant(ctx contractapi.TransactionContextInterface, landID string, grantID string) (*PowerOfAttorney, error) {
+	key, err := grantKey(ctx, landID, grantID)
+	if err != nil {
+		return nil, err
+	}
+	grantBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read power of attorney: %v", err)
+	}
+	if grantBytes == nil {
+		return nil, fmt.Errorf("power of attorney with ID %s does not exist on land %s", grantID, landID)
+	}
+	var grant PowerOfAttorney
+	err = json.Unmarshal(grantBytes, &grant)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling power of attorney: %v", err)
+	}
+	return &grant, nil
+}
+
+// putGrant writes a power of attorney to the public ledger
+func putGrant(ctx contractapi.TransactionContextInterface, grant *PowerOfAttorney) error {
+	grantJSON, err := json.Marshal(grant)
+	if err != nil {
+		return fmt.Errorf("failed to marshal power of attorney: %v", err)
+	}
+	key, err := grantKey(ctx, grant.LandID, grant.GrantID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, grantJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store power of attorney: %v", err)
+	}
+	return nil
+}
+
+func grantKey(ctx contractapi.TransactionContextInterface, landID string, grantID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("poa", []string{landID, grantID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create power of attorney key: %v", err)
+	}
+	return key, nil
+}
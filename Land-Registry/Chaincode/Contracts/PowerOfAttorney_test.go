@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPowerOfAttorneyScopes(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	agent := newTestIdentity(t, "agent", "Org1MSP", RoleSeller)
+	l.listLand("L1")
+	expiry := l.now.Add(24 * time.Hour).Format(time.RFC3339)
+
+	l.mustFail(contract.GrantPowerOfAttorney(l.as(l.seller, nil), "L1", "G1", "agent", "Org3MSP", `["list"]`, expiry), "is not an org of the seller or buyer party")
+	l.mustFail(contract.GrantPowerOfAttorney(l.as(l.buyer, nil), "L1", "G1", "agent", "Org1MSP", `["list"]`, expiry), "only the current owner can grant list power over land L1")
+	l.mustFail(contract.GrantPowerOfAttorney(l.as(l.seller, nil), "L1", "G1", "agent", "Org1MSP", `["buy"]`, expiry), "only a Buyer who does not hold land L1 can grant buy power")
+	l.mustFail(contract.GrantPowerOfAttorney(l.as(l.seller, nil), "L1", "G1", "agent", "Org1MSP", `["sell"]`, expiry), `unknown scope "sell"`)
+	l.mustFail(contract.GrantPowerOfAttorney(l.as(l.seller, nil), "L1", "G1", "agent", "Org1MSP", `["list"]`, l.now.Format(time.RFC3339)), "is already in the past")
+	l.must(contract.GrantPowerOfAttorney(l.as(l.seller, nil), "L1", "G1", "agent", "Org1MSP", `["list"]`, expiry))
+
+	l.must(contract.UpdateSellingPrice(l.as(agent, nil), "L1", 90000000))
+	if land := l.land("L1"); land.PriceMinor != 90000000 || land.Owner != "seller" {
+		t.Fatalf("land owned by %s at %d after the agent re-priced it", land.Owner, land.PriceMinor)
+	}
+	actions, err := contract.GetAgentActions(l.as(l.registrar, nil), "L1")
+	l.must(err)
+	if len(actions) != 1 || actions[0].Action != "UpdateSellingPrice" || actions[0].GrantID != "G1" ||
+		actions[0].Agent != "agent" || actions[0].Principal != "seller" || actions[0].TxID == "" {
+		t.Fatalf("unexpected agent actions: %+v", actions)
+	}
+
+	// A list grant does not cover leasing, and only the owner's own grants count
+	l.mustFail(contract.RegisterLease(l.as(agent, l.leaseTerms("Org2MSP", 0)), "L1", "LS1"), "only the current owner or their attorney can modify land L1")
+	l.mustFail(contract.UpdateSellingPrice(l.as(l.coBuyer, nil), "L1", 80000000), "only the current owner or their attorney can modify land L1")
+
+	l.mustFail(contract.RevokePowerOfAttorney(l.as(agent, nil), "L1", "G1"), "only the principal can revoke power of attorney G1")
+	l.must(contract.RevokePowerOfAttorney(l.as(l.seller, nil), "L1", "G1"))
+	l.mustFail(contract.RevokePowerOfAttorney(l.as(l.seller, nil), "L1", "G1"), "power of attorney G1 is already revoked")
+	l.mustFail(contract.DelistLand(l.as(agent, nil), "L1"), "only the current owner or their attorney can modify land L1")
+
+	actions, err = contract.GetAgentActions(l.as(l.registrar, nil), "L1")
+	l.must(err)
+	if len(actions) != 1 {
+		t.Fatalf("failed agent attempts were recorded: %+v", actions)
+	}
+}
+
+func TestPowerOfAttorneyExpires(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	agent := newTestIdentity(t, "agent", "Org1MSP", RoleSeller)
+	l.listLand("L1")
+
+	l.must(contract.GrantPowerOfAttorney(l.as(l.seller, nil), "L1", "G1", "agent", "Org1MSP", `["list","lease"]`, l.now.Add(time.Hour).Format(time.RFC3339)))
+	l.must(contract.DelistLand(l.as(agent, nil), "L1"))
+
+	l.advance(2 * time.Hour)
+	l.mustFail(contract.RegisterLease(l.as(agent, l.leaseTerms("Org2MSP", 0)), "L1", "LS1"), "only the current owner or their attorney can modify land L1")
+
+	grants, err := contract.GetPowersOfAttorney(l.as(l.registrar, nil), "L1")
+	l.must(err)
+	if len(grants) != 1 || grants[0].Status != GrantExpired {
+		t.Fatalf("unexpected grants after expiry: %+v", grants)
+	}
+}
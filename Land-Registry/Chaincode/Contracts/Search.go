@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const maxSearchPageSize = 100
+
+// LandSearchFilter narrows SearchLands. Empty fields are not filtered on and a
+// missing status defaults to For Sale. Prices are in minor currency units and
+// sizes are in SizeUnit (square metres when omitted).
+type LandSearchFilter struct {
+	NearbyCity  string   `json:"nearbyCity,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	WaterSource string   `json:"waterSource,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	MinPrice    *int64   `json:"minPrice,omitempty"`
+	MaxPrice    *int64   `json:"maxPrice,omitempty"`
+	MinSize     *float64 `json:"minSize,omitempty"`
+	MaxSize     *float64 `json:"maxSize,omitempty"`
+	SizeUnit    string   `json:"sizeUnit,omitempty"`
+}
+
+// LandSearchPage is one page of SearchLands results. Pass Bookmark back to get the next page.
+type LandSearchPage struct {
+	Lands               []*Land `json:"lands"`
+	Bookmark            string  `json:"bookmark"`
+	FetchedRecordsCount int32   `json:"fetchedRecordsCount"`
+}
+
+// Anyone can page through lands matching a filter, using CouchDB bookmarks
+func (c *LandContract) SearchLands(ctx contractapi.TransactionContextInterface, filterJSON string, pageSize int32, bookmark string) (*LandSearchPage, error) {
+	if pageSize <= 0 || pageSize > maxSearchPageSize {
+		return nil, fmt.Errorf("pageSize must be between 1 and %d", maxSearchPageSize)
+	}
+
+	var filter LandSearchFilter
+	if filterJSON != "" {
+		err := json.Unmarshal([]byte(filterJSON), &filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse search filter: %v", err)
+		}
+	}
+
+	query, err := buildSearchQuery(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search lands: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := LandSearchPage{Lands: []*Land{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var land Land
+		err = json.Unmarshal(queryResponse.Value, &land)
+		if err != nil {
+			return nil, err
+		}
+		page.Lands = append(page.Lands, &land)
+	}
+
+	page.Bookmark = metadata.Bookmark
+	page.FetchedRecordsCount = metadata.FetchedRecordsCount
+
+	return &page, nil
+}
+
+// buildSearchQuery turns a filter into a CouchDB selector
+func buildSearchQuery(filter *LandSearchFilter) (string, error) {
+	status := filter.Status
+	if status == "" {
+		status = StatusForSale
+	}
+	if !isLandStatus(status) {
+		return "", fmt.Errorf("unknown land status %q", status)
+	}
+
+	selector := map[string]interface{}{"status": status}
+	if filter.NearbyCity != "" {
+		selector["nearbyCity"] = filter.NearbyCity
+	}
+	if filter.Type != "" {
+		selector["type"] = filter.Type
+	}
+	if filter.WaterSource != "" {
+		selector["waterSource"] = filter.WaterSource
+	}
+
+	if priceRange := rangeSelector(filter.MinPrice, filter.MaxPrice); priceRange != nil {
+		selector["priceMinor"] = priceRange
+	}
+
+	if filter.MinSize != nil || filter.MaxSize != nil {
+		unit := filter.SizeUnit
+		if unit == "" {
+			unit = UnitSqm
+		}
+		var minSqm, maxSqm *float64
+		if filter.MinSize != nil {
+			sqm, err := sizeBoundInSqm(*filter.MinSize, unit)
+			if err != nil {
+				return "", fmt.Errorf("invalid minSize: %v", err)
+			}
+			minSqm = &sqm
+		}
+		if filter.MaxSize != nil {
+			sqm, err := sizeBoundInSqm(*filter.MaxSize, unit)
+			if err != nil {
+				return "", fmt.Errorf("invalid maxSize: %v", err)
+			}
+			maxSqm = &sqm
+		}
+		selector["areaSqm"] = rangeSelector(minSqm, maxSqm)
+	}
+
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return "", fmt.Errorf("failed to build search query: %v", err)
+	}
+
+	return string(queryJSON), nil
+}
+
+// sizeBoundInSqm converts a size bound to square metres. Unlike a parcel's area,
+// a bound may be zero.
+func sizeBoundInSqm(size float64, unit string) (float64, error) {
+	if size == 0 {
+		_, err := areaInSqm(1, unit)
+		return 0, err
+	}
+	return areaInSqm(size, unit)
+}
+
+func isLandStatus(status string) bool {
+	switch status {
+	case StatusForSale, StatusInAuction, StatusSold, StatusDelisted, StatusRetired:
+		return true
+	}
+	return false
+}
+
+// rangeSelector builds a CouchDB $gte/$lte clause, or nil when neither bound is set
+func rangeSelector[T int64 | float64](min *T, max *T) map[string]interface{} {
+	if min == nil && max == nil {
+		return nil
+	}
+
+	clause := map[string]interface{}{}
+	if min != nil {
+		clause["$gte"] = *min
+	}
+	if max != nil {
+		clause["$lte"] = *max
+	}
+	return clause
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildSearchQuery(t *testing.T) {
+	zero := 0.0
+	one := 1.0
+	two := 2.5
+	negative := -1.0
+	minPrice := int64(0)
+	maxPrice := int64(50000000)
+
+	tests := []struct {
+		name    string
+		filter  LandSearchFilter
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "defaults to lands for sale",
+			filter: LandSearchFilter{},
+			want:   `{"selector":{"status":"For Sale"}}`,
+		},
+		{
+			name:   "exact fields and a price range",
+			filter: LandSearchFilter{NearbyCity: "Kochi", Type: "Agricultural", WaterSource: "Well", Status: StatusSold, MinPrice: &minPrice, MaxPrice: &maxPrice},
+			want:   `{"selector":{"nearbyCity":"Kochi","priceMinor":{"$gte":0,"$lte":50000000},"status":"Sold","type":"Agricultural","waterSource":"Well"}}`,
+		},
+		{
+			name:   "sizes default to square metres",
+			filter: LandSearchFilter{MinSize: &one, MaxSize: &two},
+			want:   `{"selector":{"areaSqm":{"$gte":1,"$lte":2.5},"status":"For Sale"}}`,
+		},
+		{
+			name:   "sizes convert from their unit",
+			filter: LandSearchFilter{MinSize: &one, MaxSize: &two, SizeUnit: UnitAcre},
+			want:   `{"selector":{"areaSqm":{"$gte":4046.8564,"$lte":10117.1411},"status":"For Sale"}}`,
+		},
+		{
+			name:   "zero bounds are allowed",
+			filter: LandSearchFilter{MinSize: &zero, SizeUnit: UnitHectare},
+			want:   `{"selector":{"areaSqm":{"$gte":0},"status":"For Sale"}}`,
+		},
+		{
+			name:   "a zero maximum matches nothing larger",
+			filter: LandSearchFilter{MaxSize: &zero},
+			want:   `{"selector":{"areaSqm":{"$lte":0},"status":"For Sale"}}`,
+		},
+		{
+			name:    "negative size",
+			filter:  LandSearchFilter{MinSize: &negative},
+			wantErr: "invalid minSize: area must be a positive number",
+		},
+		{
+			name:    "unknown unit, even for a zero bound",
+			filter:  LandSearchFilter{MaxSize: &zero, SizeUnit: "bigha"},
+			wantErr: `invalid maxSize: unknown area unit "bigha"`,
+		},
+		{
+			name:    "unknown status",
+			filter:  LandSearchFilter{Status: "Leased"},
+			wantErr: `unknown land status "Leased"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := buildSearchQuery(&test.filter)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected an error containing %q, got %s, %v", test.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Fatalf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchLandsPages(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	for _, landID := range []string{"L1", "L2", "L3"} {
+		l.listLand(landID)
+	}
+	l.must(contract.DelistLand(l.as(l.seller, nil), "L2"))
+
+	l.mustFail(func() error {
+		_, err := contract.SearchLands(l.as(l.buyer, nil), "", 0, "")
+		return err
+	}(), "pageSize must be between 1 and 100")
+
+	var found []string
+	bookmark := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("search did not finish, found %v", found)
+		}
+		page, err := contract.SearchLands(l.as(l.buyer, nil), `{"minSize":0,"maxSize":1,"sizeUnit":"acre"}`, 1, bookmark)
+		l.must(err)
+		if len(page.Lands) == 0 {
+			break
+		}
+		if page.FetchedRecordsCount != 1 || page.Bookmark == bookmark {
+			t.Fatalf("unexpected page after bookmark %q: %+v", bookmark, page)
+		}
+		found = append(found, page.Lands[0].LandID)
+		bookmark = page.Bookmark
+	}
+	if !reflect.DeepEqual(found, []string{"L1", "L3"}) {
+		t.Fatalf("found %v, want the lands still for sale", found)
+	}
+
+	filter, err := json.Marshal(LandSearchFilter{Status: StatusDelisted})
+	l.must(err)
+	page, err := contract.SearchLands(l.as(l.buyer, nil), string(filter), 10, "")
+	l.must(err)
+	if len(page.Lands) != 1 || page.Lands[0].LandID != "L2" {
+		t.Fatalf("unexpected delisted lands: %+v", page.Lands)
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const collectionSellerLandRegistry = "collectionSellerLandRegistry"
+
+// SellerTitle holds the seller's title documents, kept private between Seller and Registry
+type SellerTitle struct {
+	LandID                 string `json:"landID"`
+	SellerName             string `json:"sellerName"`
+	SellerKYC              string `json:"sellerKYC"`
+	PriorDeedHash          string `json:"priorDeedHash"`
+	EncumbranceDeclaration string `json:"encumbranceDeclaration"`
+	SubmittedBy            string `json:"submittedBy"`
+	SubmittedAt            string `json:"submittedAt"`
+	Verified               bool   `json:"verified"`
+	VerifiedBy             string `json:"verifiedBy,omitempty"`
+	VerifiedAt             string `json:"verifiedAt,omitempty"`
+}
+
+// Seller (Org1, current owner or their attorney) submits title documents privately
+// to the Registry. Submitting again replaces the documents and clears any earlier
+// verification.
+func (c *LandContract) SubmitSellerTitle(ctx contractapi.TransactionContextInterface, landID string) error {
+	err := authorize(ctx, "SubmitSellerTitle")
+	if err != nil {
+		return err
+	}
+
+	land, agent, err := readManagedLand(ctx, "SubmitSellerTitle", landID)
+	if err != nil {
+		return err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient data: %v", err)
+	}
+	titleData, ok := transient["sellerTitle"]
+	if !ok {
+		return fmt.Errorf("sellerTitle key missing in transient data")
+	}
+
+	var title SellerTitle
+	err = json.Unmarshal(titleData, &title)
+	if err != nil {
+		return fmt.Errorf("failed to parse seller title: %v", err)
+	}
+	if title.PriorDeedHash == "" || title.SellerKYC == "" || title.EncumbranceDeclaration == "" {
+		return fmt.Errorf("priorDeedHash, sellerKYC and encumbranceDeclaration are required in sellerTitle")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	title.LandID = landID
+	title.SubmittedBy = land.Owner
+	title.SubmittedAt = now.Format(time.RFC3339)
+	title.Verified = false
+	title.VerifiedBy = ""
+	title.VerifiedAt = ""
+
+	err = putSellerTitle(ctx, &title)
+	if err != nil {
+		return err
+	}
+
+	if land.TitleVerified {
+		land.TitleVerified = false
+		err = putLand(ctx, land)
+		if err != nil {
+			return err
+		}
+	}
+
+	event := landEvent(land)
+	event.Agent = agent
+	return emitEvent(ctx, EventSellerTitleSubmitted, event)
+}
+
+// Land Registry (Org3) verifies the seller's title documents, clearing the land for sale
+func (c *LandContract) VerifySellerTitle(ctx contractapi.TransactionContextInterface, landID string) error {
+	err := authorize(ctx, "VerifySellerTitle")
+	if err != nil {
+		return err
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+
+	title, err := readSellerTitle(ctx, landID)
+	if err != nil {
+		return err
+	}
+	if title.SubmittedBy != land.Owner {
+		return fmt.Errorf("title documents for land %s were not submitted by its current owner", landID)
+	}
+
+	officerID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	title.Verified = true
+	title.VerifiedBy = officerID
+	title.VerifiedAt = now.Format(time.RFC3339)
+
+	err = putSellerTitle(ctx, title)
+	if err != nil {
+		return err
+	}
+
+	land.TitleVerified = true
+
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventSellerTitleVerified, landEvent(land))
+}
+
+// Land Registry (Org3) or the Seller (Org1, current owner) reads the title documents
+func (c *LandContract) GetSellerTitle(ctx contractapi.TransactionContextInterface, landID string) (*SellerTitle, error) {
+	officer, err := isAuthorized(ctx, "GetSellerTitle")
+	if err != nil {
+		return nil, err
+	}
+	if !officer {
+		seller, err := callerInParty(ctx, PartySeller)
+		if err != nil {
+			return nil, err
+		}
+		if !seller {
+			return nil, fmt.Errorf("only %s", accessPolicy["GetSellerTitle"].denied)
+		}
+		if _, err := readOwnedLand(ctx, landID); err != nil {
+			return nil, err
+		}
+	}
+
+	return readSellerTitle(ctx, landID)
+}
+
+// readSellerTitle loads title documents from the Seller/Registry private collection
+func readSellerTitle(ctx contractapi.TransactionContextInterface, landID string) (*SellerTitle, error) {
+	titleBytes, err := ctx.GetStub().GetPrivateData(collectionSellerLandRegistry, landID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seller title: %v", err)
+	}
+	if titleBytes == nil {
+		return nil, fmt.Errorf("no title documents submitted for land %s", landID)
+	}
+
+	var title SellerTitle
+	err = json.Unmarshal(titleBytes, &title)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling seller title: %v", err)
+	}
+
+	return &title, nil
+}
+
+// putSellerTitle writes title documents to the Seller/Registry private collection
+func putSellerTitle(ctx contractapi.TransactionContextInterface, title *SellerTitle) error {
+	titleJSON, err := json.Marshal(title)
+	if err != nil {
+		return fmt.Errorf("failed to marshal seller title: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(collectionSellerLandRegistry, title.LandID, titleJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store seller title: %v", err)
+	}
+
+	return nil
+}
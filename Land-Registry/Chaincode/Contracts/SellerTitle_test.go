@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// sellerTitle is the transient a seller submits their title documents in
+func (l *testLedger) sellerTitle(priorDeedHash string) map[string][]byte {
+	l.t.Helper()
+	title, err := json.Marshal(SellerTitle{SellerName: "Seller", SellerKYC: "kyc-1", PriorDeedHash: priorDeedHash, EncumbranceDeclaration: "none"})
+	l.must(err)
+	return map[string][]byte{"sellerTitle": title}
+}
+
+func TestSellerTitleVerification(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+
+	l.mustFail(contract.SubmitSellerTitle(l.as(l.seller, nil), "L1"), "sellerTitle key missing in transient data")
+	l.mustFail(contract.SubmitSellerTitle(l.as(l.seller, l.sellerTitle("")), "L1"), "priorDeedHash, sellerKYC and encumbranceDeclaration are required in sellerTitle")
+	l.mustFail(contract.SubmitSellerTitle(l.as(l.buyer, l.sellerTitle("deed-1")), "L1"), "only a Seller can submit title documents")
+	l.mustFail(contract.VerifySellerTitle(l.as(l.registrar, nil), "L1"), "no title documents submitted for land L1")
+	l.must(contract.SubmitSellerTitle(l.as(l.seller, l.sellerTitle("deed-1")), "L1"))
+
+	l.mustFail(contract.VerifySellerTitle(l.as(l.seller, nil), "L1"), "only a LandRegistry registrar can verify seller title")
+	l.mustFail(contract.VerifySellerTitle(l.as(l.buyer, nil), "L1"), "only a LandRegistry registrar can verify seller title")
+	l.must(contract.VerifySellerTitle(l.as(l.registrar, nil), "L1"))
+	if !l.land("L1").TitleVerified {
+		t.Fatalf("land L1 is not cleared for sale after verification")
+	}
+
+	_, err := contract.GetSellerTitle(l.as(l.buyer, nil), "L1")
+	l.mustFail(err, "only the Seller or a LandRegistry officer can view seller title")
+	title, err := contract.GetSellerTitle(l.as(l.seller, nil), "L1")
+	l.must(err)
+	if !title.Verified || title.VerifiedBy != "registrar" || title.SubmittedBy != "seller" || title.PriorDeedHash != "deed-1" {
+		t.Fatalf("unexpected seller title: %+v", title)
+	}
+
+	// Resubmitting replaces the documents and needs a fresh verification
+	l.must(contract.SubmitSellerTitle(l.as(l.seller, l.sellerTitle("deed-2")), "L1"))
+	title, err = contract.GetSellerTitle(l.as(l.registrar, nil), "L1")
+	l.must(err)
+	if title.Verified || title.VerifiedBy != "" || title.PriorDeedHash != "deed-2" || l.land("L1").TitleVerified {
+		t.Fatalf("resubmitted title still verified: %+v", title)
+	}
+}
+
+func TestSellerTitleFromPreviousOwner(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.must(contract.SubmitSellerTitle(l.as(l.seller, l.sellerTitle("deed-1")), "L1"))
+
+	land := l.land("L1")
+	setHoldings(land, []OwnerShare{{OwnerID: "buyer", OwnerMSP: "Org2MSP", ShareBasisPoints: fullShare}})
+	l.putLand(land)
+	l.mustFail(contract.VerifySellerTitle(l.as(l.registrar, nil), "L1"), "title documents for land L1 were not submitted by its current owner")
+}
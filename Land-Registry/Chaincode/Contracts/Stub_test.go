@@ -0,0 +1,483 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"container/list"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testStub is a MockStub that also answers the private data, rich query and
+// history calls shimtest leaves unimplemented, and keeps the names of emitted events
+type testStub struct {
+	*shimtest.MockStub
+	events  []string
+	history map[string][]*queryresult.KeyModification
+}
+
+// stubSnapshot is the world state before a transaction, kept to undo its writes
+// if it fails, as a peer would by not committing it
+type stubSnapshot struct {
+	state        map[string][]byte
+	private      map[string]map[string][]byte
+	endorsements map[string]map[string][]byte
+	events       int
+	history      map[string][]*queryresult.KeyModification
+}
+
+func (s *testStub) snapshot() *stubSnapshot {
+	state := map[string][]byte{}
+	for key, value := range s.State {
+		state[key] = value
+	}
+	history := map[string][]*queryresult.KeyModification{}
+	for key, modifications := range s.history {
+		history[key] = append([]*queryresult.KeyModification(nil), modifications...)
+	}
+	return &stubSnapshot{
+		state:        state,
+		private:      copyCollections(s.PvtState),
+		endorsements: copyCollections(s.EndorsementPolicies),
+		events:       len(s.events),
+		history:      history,
+	}
+}
+
+func (s *testStub) restore(snapshot *stubSnapshot) {
+	s.State = snapshot.state
+	s.PvtState = snapshot.private
+	s.EndorsementPolicies = snapshot.endorsements
+	s.events = s.events[:snapshot.events]
+	s.history = snapshot.history
+
+	keys := make([]string, 0, len(s.State))
+	for key := range s.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	s.Keys = list.New()
+	for _, key := range keys {
+		s.Keys.PushBack(key)
+	}
+}
+
+func copyCollections(collections map[string]map[string][]byte) map[string]map[string][]byte {
+	copied := map[string]map[string][]byte{}
+	for collection, values := range collections {
+		copied[collection] = map[string][]byte{}
+		for key, value := range values {
+			copied[collection][key] = value
+		}
+	}
+	return copied
+}
+
+func (s *testStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	value, err := s.GetPrivateData(collection, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *testStub) DelPrivateData(collection string, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+// GetStateByRange reads an empty endKey as unbounded, as a peer does; the mock
+// only does so when startKey is empty too
+func (s *testStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if startKey != "" && endKey == "" {
+		endKey = string(utf8.MaxRune)
+	}
+	return s.MockStub.GetStateByRange(startKey, endKey)
+}
+
+// GetQueryResult answers a CouchDB selector over the public state, for the
+// operators the contracts use: equality, $gte, $lte, $ne, $or and $elemMatch
+func (s *testStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := s.queryState(query, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	return &resultsIterator{results: results}, nil
+}
+
+// GetQueryResultWithPagination pages through GetQueryResult; the bookmark is
+// the last key of the previous page
+func (s *testStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	results, err := s.queryState(query, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(results)), Bookmark: bookmark}
+	if len(results) > 0 {
+		metadata.Bookmark = results[len(results)-1].Key
+	}
+	return &resultsIterator{results: results}, metadata, nil
+}
+
+func (s *testStub) queryState(query string, pageSize int32, bookmark string) ([]*queryresult.KV, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	err := json.Unmarshal([]byte(query), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+
+	keys := make([]string, 0, len(s.State))
+	for key := range s.State {
+		if key > bookmark {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	results := []*queryresult.KV{}
+	for _, key := range keys {
+		var document map[string]interface{}
+		if json.Unmarshal(s.State[key], &document) != nil || !matchesSelector(document, parsed.Selector) {
+			continue
+		}
+		results = append(results, &queryresult.KV{Key: key, Value: s.State[key]})
+		if pageSize > 0 && len(results) == int(pageSize) {
+			break
+		}
+	}
+	return results, nil
+}
+
+func matchesSelector(document map[string]interface{}, selector map[string]interface{}) bool {
+	for field, condition := range selector {
+		if field == "$or" {
+			matched := false
+			for _, alternative := range condition.([]interface{}) {
+				matched = matched || matchesSelector(document, alternative.(map[string]interface{}))
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+
+		value := document[field]
+		operators, ok := condition.(map[string]interface{})
+		if !ok {
+			if !reflect.DeepEqual(value, condition) {
+				return false
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			number, _ := value.(float64)
+			switch operator {
+			case "$gte":
+				if _, ok := value.(float64); !ok || number < operand.(float64) {
+					return false
+				}
+			case "$lte":
+				if _, ok := value.(float64); !ok || number > operand.(float64) {
+					return false
+				}
+			case "$ne":
+				if reflect.DeepEqual(value, operand) {
+					return false
+				}
+			case "$elemMatch":
+				elements, _ := value.([]interface{})
+				matched := false
+				for _, element := range elements {
+					if object, ok := element.(map[string]interface{}); ok && matchesSelector(object, operand.(map[string]interface{})) {
+						matched = true
+					}
+				}
+				if !matched {
+					return false
+				}
+			default:
+				panic("unsupported selector operator " + operator)
+			}
+		}
+	}
+	return true
+}
+
+// resultsIterator walks the results of a query
+type resultsIterator struct {
+	results []*queryresult.KV
+}
+
+func (i *resultsIterator) HasNext() bool { return len(i.results) > 0 }
+func (i *resultsIterator) Close() error  { return nil }
+
+func (i *resultsIterator) Next() (*queryresult.KV, error) {
+	result := i.results[0]
+	i.results = i.results[1:]
+	return result, nil
+}
+
+// PutState and DelState also record the key's history, keeping one version per
+// transaction as a peer's history database does
+func (s *testStub) PutState(key string, value []byte) error {
+	err := s.MockStub.PutState(key, value)
+	if err == nil {
+		s.recordHistory(key, &queryresult.KeyModification{Value: value})
+	}
+	return err
+}
+
+func (s *testStub) DelState(key string) error {
+	err := s.MockStub.DelState(key)
+	if err == nil {
+		s.recordHistory(key, &queryresult.KeyModification{IsDelete: true})
+	}
+	return err
+}
+
+func (s *testStub) recordHistory(key string, modification *queryresult.KeyModification) {
+	if s.history == nil {
+		s.history = map[string][]*queryresult.KeyModification{}
+	}
+	modification.TxId = s.TxID
+	modification.Timestamp = s.TxTimestamp
+	modifications := s.history[key]
+	if last := len(modifications) - 1; last >= 0 && modifications[last].TxId == s.TxID {
+		modifications = modifications[:last]
+	}
+	s.history[key] = append(modifications, modification)
+}
+
+// GetHistoryForKey returns a key's versions newest first, as a peer does
+func (s *testStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	modifications := s.history[key]
+	newestFirst := make([]*queryresult.KeyModification, len(modifications))
+	for i, modification := range modifications {
+		newestFirst[len(modifications)-1-i] = modification
+	}
+	return &historyIterator{modifications: newestFirst}, nil
+}
+
+// historyIterator walks the versions of a key
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+func (i *historyIterator) HasNext() bool { return len(i.modifications) > 0 }
+func (i *historyIterator) Close() error  { return nil }
+
+func (i *historyIterator) Next() (*queryresult.KeyModification, error) {
+	modification := i.modifications[0]
+	i.modifications = i.modifications[1:]
+	return modification, nil
+}
+
+func (s *testStub) SetEvent(name string, payload []byte) error {
+	s.events = append(s.events, name)
+	return nil
+}
+
+// testIdentity is a client identity with a role attribute and an ECDSA
+// certificate whose key can sign lien consents
+type testIdentity struct {
+	id   string
+	msp  string
+	role string
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestIdentity(t *testing.T, id string, msp string, role string) *testIdentity {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: id, OrganizationalUnit: []string{"client"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return &testIdentity{id: id, msp: msp, role: role, key: key, cert: cert}
+}
+
+func (i *testIdentity) GetID() (string, error)    { return i.id, nil }
+func (i *testIdentity) GetMSPID() (string, error) { return i.msp, nil }
+
+func (i *testIdentity) GetAttributeValue(name string) (string, bool, error) {
+	if name != roleAttribute || i.role == "" {
+		return "", false, nil
+	}
+	return i.role, true, nil
+}
+
+func (i *testIdentity) AssertAttributeValue(name string, value string) error {
+	if actual, found, _ := i.GetAttributeValue(name); !found || actual != value {
+		return fmt.Errorf("attribute %s is not %s", name, value)
+	}
+	return nil
+}
+
+func (i *testIdentity) GetX509Certificate() (*x509.Certificate, error) { return i.cert, nil }
+
+// testContext is the transaction context a contract method sees
+type testContext struct {
+	stub     shim.ChaincodeStubInterface
+	identity cid.ClientIdentity
+}
+
+func (c *testContext) GetStub() shim.ChaincodeStubInterface  { return c.stub }
+func (c *testContext) GetClientIdentity() cid.ClientIdentity { return c.identity }
+
+// testLedger runs transactions one after another against a mock world state
+type testLedger struct {
+	t    *testing.T
+	stub *testStub
+	now  time.Time
+	txs  int
+	last *stubSnapshot // world state before the latest transaction
+
+	seller    *testIdentity
+	buyer     *testIdentity
+	coBuyer   *testIdentity
+	registrar *testIdentity
+	lender    *testIdentity
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	return &testLedger{
+		t:         t,
+		stub:      &testStub{MockStub: shimtest.NewMockStub("landcontract", nil)},
+		now:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		seller:    newTestIdentity(t, "seller", "Org1MSP", RoleSeller),
+		buyer:     newTestIdentity(t, "buyer", "Org2MSP", RoleBuyer),
+		coBuyer:   newTestIdentity(t, "cobuyer", "Org2MSP", RoleBuyer),
+		registrar: newTestIdentity(t, "registrar", "Org3MSP", RoleRegistrar),
+		lender:    newTestIdentity(t, "lender", "Org2MSP", RoleLender),
+	}
+}
+
+// as starts a transaction by an identity, with optional transient data, and
+// returns its context. Writes are visible to the next transaction at once,
+// unless mustFail undoes them.
+func (l *testLedger) as(identity *testIdentity, transient map[string][]byte) contractapi.TransactionContextInterface {
+	l.txs++
+	l.last = l.stub.snapshot()
+	l.stub.MockTransactionStart(fmt.Sprintf("tx%d", l.txs))
+	l.stub.TxTimestamp = timestamppb.New(l.now)
+	l.stub.TransientMap = transient
+	return &testContext{stub: l.stub, identity: identity}
+}
+
+// advance moves the transaction clock forward
+func (l *testLedger) advance(d time.Duration) {
+	l.now = l.now.Add(d)
+}
+
+// must fails the test on an unexpected error
+func (l *testLedger) must(err error) {
+	l.t.Helper()
+	if err != nil {
+		l.t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// mustFail fails the test unless err is set and its message contains want,
+// and undoes the failed transaction's writes
+func (l *testLedger) mustFail(err error, want string) {
+	l.t.Helper()
+	if err == nil || !strings.Contains(err.Error(), want) {
+		l.t.Fatalf("expected an error containing %q, got %v", want, err)
+	}
+	l.stub.restore(l.last)
+}
+
+// land reads a land straight from the world state
+func (l *testLedger) land(landID string) *Land {
+	l.t.Helper()
+	land, err := readLand(l.as(l.registrar, nil), landID)
+	l.must(err)
+	return land
+}
+
+// putLand writes a land straight to the world state
+func (l *testLedger) putLand(land *Land) {
+	l.t.Helper()
+	l.must(putLand(l.as(l.registrar, nil), land))
+}
+
+// privateJSON returns a private record's stored bytes
+func (l *testLedger) privateJSON(collection string, key string) []byte {
+	l.t.Helper()
+	value := l.stub.PvtState[collection][key]
+	if value == nil {
+		l.t.Fatalf("no %s record %s", collection, key)
+	}
+	return value
+}
+
+// listLand has the seller list a new land for 1 acre at 10,00,000.00
+func (l *testLedger) listLand(landID string) {
+	l.t.Helper()
+	contract := &LandContract{}
+	l.must(contract.ListLand(l.as(l.seller, nil), landID, "Village Road", 1, UnitAcre, "Agricultural", "Loam", "Well", "NH44", "Nagpur", "21.1,79.0", 100000000))
+}
+
+// requestToBuy has an identity make an offer on a land
+func (l *testLedger) requestToBuy(identity *testIdentity, offerID string, landID string, coBuyers []CoBuyer) {
+	l.t.Helper()
+	contract := &LandContract{}
+	l.must(contract.RequestToBuy(l.as(identity, l.buyerRequest(identity, landID, coBuyers)), offerID))
+}
+
+// buyerRequest is the transient data of an offer expiring in a day
+func (l *testLedger) buyerRequest(identity *testIdentity, landID string, coBuyers []CoBuyer) map[string][]byte {
+	l.t.Helper()
+	request, err := json.Marshal(map[string]interface{}{
+		"landID":     landID,
+		"buyerName":  identity.id,
+		"aadhar":     "123412341234",
+		"priceMinor": 100000000,
+		"expiry":     l.now.Add(24 * time.Hour).Format(time.RFC3339),
+		"coBuyers":   coBuyers,
+	})
+	l.must(err)
+	return map[string][]byte{"buyerRequest": request}
+}
+
+// offer presents an offer as its parties do, from the stored record
+func (l *testLedger) offer(offerID string) map[string][]byte {
+	return map[string][]byte{"offer": l.privateJSON(collectionBuyerSeller, offerID)}
+}
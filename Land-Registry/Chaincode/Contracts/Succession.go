@@ -0,0 +1,675 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	SuccessionPending   = "Pending"
+	SuccessionFinalized = "Finalized"
+	SuccessionRejected  = "Rejected"
+	SuccessionCancelled = "Cancelled"
+
+	ObjectionPending   = "Pending"
+	ObjectionDismissed = "Dismissed"
+	ObjectionUpheld    = "Upheld"
+)
+
+// fullShare is 100% in basis points
+const fullShare = 10000
+
+// defaultObjectionDays applies until SetOrgConfig sets successionObjectionDays
+const defaultObjectionDays = 30
+
+// OwnerShare is one holder's part of a jointly held title
+type OwnerShare struct {
+	OwnerID          string `json:"ownerID"`
+	OwnerMSP         string `json:"ownerMSP"`
+	ShareBasisPoints int    `json:"shareBasisPoints"` // 10000 is the whole title
+}
+
+// Succession passes a deceased holder's share of a title to their legal heirs by
+// will or succession certificate. The documents themselves stay off-chain.
+type Succession struct {
+	SuccessionID         string       `json:"successionID"`
+	LandID               string       `json:"landID"`
+	DeceasedID           string       `json:"deceasedID"`
+	DeathCertificateHash string       `json:"deathCertificateHash"`
+	SuccessionDocHash    string       `json:"successionDocHash"` // succession certificate or probate
+	Heirs                []OwnerShare `json:"heirs"`             // proportions of the deceased's share; the first heir takes their place
+	InitiatedAt          string       `json:"initiatedAt"`
+	ObjectionEnds        string       `json:"objectionEnds"` // RFC3339
+	Status               string       `json:"status"`        // Pending, Finalized, Rejected, Cancelled
+	FinalizedAt          string       `json:"finalizedAt,omitempty"`
+}
+
+// Objection is a claim against a pending succession, e.g. by an omitted heir
+type Objection struct {
+	SuccessionID string `json:"successionID"`
+	ObjectionID  string `json:"objectionID"`
+	Objector     string `json:"objector"`
+	ObjectorMSP  string `json:"objectorMSP"`
+	GroundsHash  string `json:"groundsHash"` // hash of the objection filed off-chain
+	RaisedAt     string `json:"raisedAt"`
+	Status       string `json:"status"` // Pending, Dismissed, Upheld
+}
+
+// Land Registry (Org3) opens a succession on the share of a land held by
+// deceasedID. heirsJSON is a list of OwnerShare whose shares add up to 10000
+// basis points and divide the deceased's share among them. The land leaves the
+// market and transfers once the objection window has passed.
+func (c *LandContract) InitiateSuccession(ctx contractapi.TransactionContextInterface, landID string, successionID string, deceasedID string, deathCertificateHash string, successionDocHash string, heirsJSON string) error {
+	err := authorize(ctx, "InitiateSuccession")
+	if err != nil {
+		return err
+	}
+	if successionID == "" || deceasedID == "" || deathCertificateHash == "" || successionDocHash == "" {
+		return fmt.Errorf("successionID, deceasedID, deathCertificateHash and successionDocHash are required")
+	}
+
+	var heirs []OwnerShare
+	err = json.Unmarshal([]byte(heirsJSON), &heirs)
+	if err != nil {
+		return fmt.Errorf("failed to parse heirs: %v", err)
+	}
+	err = validateShares(heirs)
+	if err != nil {
+		return err
+	}
+
+	land, err := readLand(ctx, landID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case land.Status == StatusRetired:
+		return fmt.Errorf("land with ID %s has been retired", landID)
+	case land.Status == StatusInAuction:
+		return fmt.Errorf("land with ID %s is in auction", landID)
+	case land.AcceptedOfferID != "":
+		return fmt.Errorf("land with ID %s has an accepted offer", landID)
+	case land.SuccessionID != "":
+		return fmt.Errorf("land with ID %s already has succession %s pending", landID, land.SuccessionID)
+	}
+	_, err = inheritShares(holdings(land), deceasedID, heirs)
+	if err != nil {
+		return err
+	}
+
+	key, err := successionKey(ctx, successionID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read succession: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("succession with ID %s already exists", successionID)
+	}
+
+	config, err := readOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	// Heirs' orgs go into the parcel's endorsement policy once it transfers
+	for _, heir := range heirs {
+		err = config.requireMSP(heir.OwnerMSP, PartySeller, PartyBuyer)
+		if err != nil {
+			return err
+		}
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	succession := Succession{
+		SuccessionID:         successionID,
+		LandID:               landID,
+		DeceasedID:           deceasedID,
+		DeathCertificateHash: deathCertificateHash,
+		SuccessionDocHash:    successionDocHash,
+		Heirs:                heirs,
+		InitiatedAt:          now.Format(time.RFC3339),
+		ObjectionEnds:        now.Add(config.objectionWindow()).Format(time.RFC3339),
+		Status:               SuccessionPending,
+	}
+	err = putSuccession(ctx, &succession)
+	if err != nil {
+		return err
+	}
+
+	// A listing by the deceased lapses
+	if land.Status == StatusForSale {
+		land.Status = StatusDelisted
+	}
+	land.SuccessionID = successionID
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.SuccessionID = successionID
+	return emitEvent(ctx, EventSuccessionInitiated, event)
+}
+
+// Anyone on the channel objects to a pending succession before its objection
+// window ends. The grounds are filed off-chain and referenced by hash.
+func (c *LandContract) RaiseObjection(ctx contractapi.TransactionContextInterface, successionID string, objectionID string, groundsHash string) error {
+	if objectionID == "" || groundsHash == "" {
+		return fmt.Errorf("objectionID and groundsHash are required")
+	}
+
+	succession, err := readSuccession(ctx, successionID)
+	if err != nil {
+		return err
+	}
+	if succession.Status != SuccessionPending {
+		return fmt.Errorf("succession %s is %s", successionID, succession.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	ends, err := time.Parse(time.RFC3339, succession.ObjectionEnds)
+	if err != nil {
+		return fmt.Errorf("succession %s has an invalid objection window: %v", successionID, err)
+	}
+	if !now.Before(ends) {
+		return fmt.Errorf("objection window for succession %s has ended", successionID)
+	}
+
+	key, err := objectionKey(ctx, successionID, objectionID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read objection: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("objection with ID %s already exists", objectionID)
+	}
+
+	objector, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+
+	objection := Objection{
+		SuccessionID: successionID,
+		ObjectionID:  objectionID,
+		Objector:     objector,
+		ObjectorMSP:  msp,
+		GroundsHash:  groundsHash,
+		RaisedAt:     now.Format(time.RFC3339),
+		Status:       ObjectionPending,
+	}
+	err = putObjection(ctx, &objection)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventSuccessionObjected, LandEvent{LandID: succession.LandID, SuccessionID: successionID, ObjectionID: objectionID})
+}
+
+// Land Registry (Org3) decides an objection. Upholding it rejects the succession
+// and releases the land; a fresh succession can then be initiated.
+func (c *LandContract) ResolveObjection(ctx contractapi.TransactionContextInterface, successionID string, objectionID string, upheld bool) error {
+	err := authorize(ctx, "ResolveObjection")
+	if err != nil {
+		return err
+	}
+
+	succession, err := readSuccession(ctx, successionID)
+	if err != nil {
+		return err
+	}
+	if succession.Status != SuccessionPending {
+		return fmt.Errorf("succession %s is %s", successionID, succession.Status)
+	}
+
+	key, err := objectionKey(ctx, successionID, objectionID)
+	if err != nil {
+		return err
+	}
+	objectionBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read objection: %v", err)
+	}
+	if objectionBytes == nil {
+		return fmt.Errorf("objection with ID %s does not exist", objectionID)
+	}
+	var objection Objection
+	err = json.Unmarshal(objectionBytes, &objection)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling objection: %v", err)
+	}
+	if objection.Status != ObjectionPending {
+		return fmt.Errorf("objection %s is already %s", objectionID, objection.Status)
+	}
+
+	objection.Status = ObjectionDismissed
+	if upheld {
+		objection.Status = ObjectionUpheld
+	}
+	err = putObjection(ctx, &objection)
+	if err != nil {
+		return err
+	}
+
+	if upheld {
+		err = closeSuccession(ctx, succession, SuccessionRejected)
+		if err != nil {
+			return err
+		}
+	}
+
+	return emitEvent(ctx, EventObjectionResolved, LandEvent{LandID: succession.LandID, SuccessionID: successionID, ObjectionID: objectionID, Status: objection.Status})
+}
+
+// Land Registry (Org3) withdraws a pending succession, e.g. one opened in error
+func (c *LandContract) CancelSuccession(ctx contractapi.TransactionContextInterface, successionID string) error {
+	err := authorize(ctx, "CancelSuccession")
+	if err != nil {
+		return err
+	}
+
+	succession, err := readSuccession(ctx, successionID)
+	if err != nil {
+		return err
+	}
+	if succession.Status != SuccessionPending {
+		return fmt.Errorf("succession %s is %s", successionID, succession.Status)
+	}
+
+	err = closeSuccession(ctx, succession, SuccessionCancelled)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventSuccessionCancelled, LandEvent{LandID: succession.LandID, SuccessionID: successionID})
+}
+
+// Land Registry (Org3) passes the deceased's share to the heirs once the
+// objection window has ended with no objection pending; the other holders keep
+// theirs. The land's ownership record, if any, is presented as "ownershipRecords"
+// (see presentedOwnershipRecords) and replaced by one for the new holders, whose
+// names and Aadhar numbers not already in it go in transient data as
+// "holderDetails", a list of CoOwner. No price or offer is involved.
+func (c *LandContract) FinalizeSuccession(ctx contractapi.TransactionContextInterface, successionID string) error {
+	err := authorize(ctx, "FinalizeSuccession")
+	if err != nil {
+		return err
+	}
+
+	succession, err := readSuccession(ctx, successionID)
+	if err != nil {
+		return err
+	}
+	if succession.Status != SuccessionPending {
+		return fmt.Errorf("succession %s is %s", successionID, succession.Status)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	ends, err := time.Parse(time.RFC3339, succession.ObjectionEnds)
+	if err != nil {
+		return fmt.Errorf("succession %s has an invalid objection window: %v", successionID, err)
+	}
+	if now.Before(ends) {
+		return fmt.Errorf("objection window for succession %s is still open until %s", successionID, succession.ObjectionEnds)
+	}
+
+	objections, err := readObjections(ctx, successionID)
+	if err != nil {
+		return err
+	}
+	for _, objection := range objections {
+		if objection.Status == ObjectionPending {
+			return fmt.Errorf("succession %s has objection %s pending", successionID, objection.ObjectionID)
+		}
+	}
+
+	err = requireNotFrozen(ctx, succession.LandID)
+	if err != nil {
+		return err
+	}
+	land, err := readLand(ctx, succession.LandID)
+	if err != nil {
+		return err
+	}
+	shares, err := inheritShares(holdings(land), succession.DeceasedID, succession.Heirs)
+	if err != nil {
+		return err
+	}
+	records, err := presentedOwnershipRecords(ctx, []string{land.LandID})
+	if err != nil {
+		return err
+	}
+	details, err := holderDetails(ctx)
+	if err != nil {
+		return err
+	}
+	previousOwner := land.Owner
+
+	setHoldings(land, shares)
+	land.SuccessionID = ""
+	land.TitleVerified = true
+	err = putLand(ctx, land)
+	if err != nil {
+		return err
+	}
+	err = setLandEndorsement(ctx, land)
+	if err != nil {
+		return err
+	}
+
+	// The deceased's ownership record no longer describes the title, so it is replaced
+	record, err := successionOwnershipRecord(land, records[land.LandID], details, succession.SuccessionDocHash, now)
+	if err != nil {
+		return err
+	}
+	err = putOwnershipRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	succession.Status = SuccessionFinalized
+	succession.FinalizedAt = now.Format(time.RFC3339)
+	err = putSuccession(ctx, succession)
+	if err != nil {
+		return err
+	}
+
+	event := landEvent(land)
+	event.PreviousOwner = previousOwner
+	event.SuccessionID = successionID
+	return emitEvent(ctx, EventSuccessionFinalized, event)
+}
+
+// Anyone reads a succession
+func (c *LandContract) GetSuccession(ctx contractapi.TransactionContextInterface, successionID string) (*Succession, error) {
+	return readSuccession(ctx, successionID)
+}
+
+// Anyone lists the objections raised against a succession
+func (c *LandContract) GetObjections(ctx contractapi.TransactionContextInterface, successionID string) ([]*Objection, error) {
+	if _, err := readSuccession(ctx, successionID); err != nil {
+		return nil, err
+	}
+	return readObjections(ctx, successionID)
+}
+
+// validateShares checks holders are named once each and their shares make up
+// the whole title
+func validateShares(shares []OwnerShare) error {
+	if len(shares) == 0 {
+		return fmt.Errorf("at least one holder is required")
+	}
+	seen := map[string]bool{}
+	total := 0
+	for _, share := range shares {
+		if share.OwnerID == "" || share.OwnerMSP == "" {
+			return fmt.Errorf("ownerID and ownerMSP are required for every holder")
+		}
+		if seen[share.OwnerID] {
+			return fmt.Errorf("holder %s is listed more than once", share.OwnerID)
+		}
+		seen[share.OwnerID] = true
+		if share.ShareBasisPoints <= 0 {
+			return fmt.Errorf("shareBasisPoints must be positive for every holder")
+		}
+		total += share.ShareBasisPoints
+	}
+	if total != fullShare {
+		return fmt.Errorf("shares add up to %d basis points, not %d", total, fullShare)
+	}
+	return nil
+}
+
+// inheritShares divides the deceased's share among the heirs in the proportions
+// of the succession, leaving the other holders' shares as they are. Heirs take
+// the deceased's place in the list, so the first heir of an owner of record
+// becomes the owner of record; an heir who already holds a share has it
+// increased. Rounding goes to the first heir.
+func inheritShares(shares []OwnerShare, deceasedID string, heirs []OwnerShare) ([]OwnerShare, error) {
+	inherited := 0
+	for _, share := range shares {
+		if share.OwnerID == deceasedID {
+			inherited = share.ShareBasisPoints
+		}
+	}
+	if inherited == 0 {
+		return nil, fmt.Errorf("%s is not a holder of the land", deceasedID)
+	}
+
+	parts := make([]int, len(heirs))
+	given := 0
+	for i, heir := range heirs {
+		if heir.OwnerID == deceasedID {
+			return nil, fmt.Errorf("the deceased cannot be listed among their heirs")
+		}
+		parts[i] = inherited * heir.ShareBasisPoints / fullShare
+		given += parts[i]
+	}
+	parts[0] += inherited - given
+	for i, heir := range heirs {
+		if parts[i] == 0 {
+			return nil, fmt.Errorf("heir %s would inherit less than one basis point", heir.OwnerID)
+		}
+	}
+
+	result := []OwnerShare{}
+	add := func(share OwnerShare) {
+		for i := range result {
+			if result[i].OwnerID == share.OwnerID {
+				result[i].ShareBasisPoints += share.ShareBasisPoints
+				return
+			}
+		}
+		result = append(result, share)
+	}
+	for _, share := range shares {
+		if share.OwnerID != deceasedID {
+			add(share)
+			continue
+		}
+		for i, heir := range heirs {
+			add(OwnerShare{OwnerID: heir.OwnerID, OwnerMSP: heir.OwnerMSP, ShareBasisPoints: parts[i]})
+		}
+	}
+	return result, nil
+}
+
+// holderDetails returns the "holderDetails" transient entry, if any
+func holderDetails(ctx contractapi.TransactionContextInterface) ([]CoOwner, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("error getting transient data: %v", err)
+	}
+	var details []CoOwner
+	if detailsData, ok := transient["holderDetails"]; ok {
+		err = json.Unmarshal(detailsData, &details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse holder details: %v", err)
+		}
+	}
+	return details, nil
+}
+
+// successionOwnershipRecord is the ownership record of a land after succession.
+// Holders keep the name and Aadhar number of the previous record, if any, unless
+// details give new ones; every holder must be named by one or the other.
+func successionOwnershipRecord(land *Land, previous *BuyerOwnership, details []CoOwner, documentHash string, now time.Time) (*BuyerOwnership, error) {
+	known := map[string]CoOwner{}
+	if previous != nil {
+		known[previous.OwnerID] = CoOwner{OwnerID: previous.OwnerID, Name: previous.BuyerName, Aadhar: previous.Aadhar}
+		for _, coOwner := range previous.CoOwners {
+			known[coOwner.OwnerID] = coOwner
+		}
+	}
+	for _, detail := range details {
+		known[detail.OwnerID] = detail
+	}
+
+	coOwners := []CoOwner{}
+	for _, share := range holdings(land) {
+		coOwner, ok := known[share.OwnerID]
+		if !ok {
+			return nil, fmt.Errorf("name and aadhar of holder %s missing in transient data", share.OwnerID)
+		}
+		coOwner.ShareBasisPoints = share.ShareBasisPoints
+		coOwners = append(coOwners, coOwner)
+	}
+
+	record := BuyerOwnership{
+		OwnerID:      land.Owner,
+		BuyerName:    coOwners[0].Name,
+		Aadhar:       coOwners[0].Aadhar,
+		DocumentHash: documentHash,
+		TransferDate: now.Format(time.RFC3339),
+		LandID:       land.LandID,
+		Location:     land.Location,
+		Size:         formatArea(land),
+		Type:         land.Type,
+		Coordinates:  land.Coordinates,
+	}
+	if len(coOwners) > 1 {
+		record.CoOwners = coOwners
+	}
+	return &record, nil
+}
+
+// closeSuccession ends a pending succession without a transfer and frees its land
+func closeSuccession(ctx contractapi.TransactionContextInterface, succession *Succession, status string) error {
+	succession.Status = status
+	err := putSuccession(ctx, succession)
+	if err != nil {
+		return err
+	}
+
+	land, err := readLand(ctx, succession.LandID)
+	if err != nil {
+		return err
+	}
+	land.SuccessionID = ""
+	return putLand(ctx, land)
+}
+
+// objectionWindow is how long a succession stays open to objections
+func (config *OrgConfig) objectionWindow() time.Duration {
+	days := config.SuccessionObjectionDays
+	if days == 0 {
+		days = defaultObjectionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// readSuccession loads a succession from the public ledger
+func readSuccession(ctx contractapi.TransactionContextInterface, successionID string) (*Succession, error) {
+	key, err := successionKey(ctx, successionID)
+	if err != nil {
+		return nil, err
+	}
+	successionBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read succession: %v", err)
+	}
+	if successionBytes == nil {
+		return nil, fmt.Errorf("succession with ID %s does not exist", successionID)
+	}
+
+	var succession Succession
+	err = json.Unmarshal(successionBytes, &succession)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling succession: %v", err)
+	}
+	return &succession, nil
+}
+
+// readObjections returns every objection to a succession
+func readObjections(ctx contractapi.TransactionContextInterface, successionID string) ([]*Objection, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("objection", []string{successionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objections: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	objections := []*Objection{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var objection Objection
+		err = json.Unmarshal(queryResponse.Value, &objection)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling objection: %v", err)
+		}
+		objections = append(objections, &objection)
+	}
+	return objections, nil
+}
+
+// putSuccession writes a succession to the public ledger
+func putSuccession(ctx contractapi.TransactionContextInterface, succession *Succession) error {
+	successionJSON, err := json.Marshal(succession)
+	if err != nil {
+		return fmt.Errorf("failed to marshal succession: %v", err)
+	}
+	key, err := successionKey(ctx, succession.SuccessionID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, successionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store succession: %v", err)
+	}
+	return nil
+}
+
+// putObjection writes an objection to the public ledger
+func putObjection(ctx contractapi.TransactionContextInterface, objection *Objection) error {
+	objectionJSON, err := json.Marshal(objection)
+	if err != nil {
+		return fmt.Errorf("failed to marshal objection: %v", err)
+	}
+	key, err := objectionKey(ctx, objection.SuccessionID, objection.ObjectionID)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, objectionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store objection: %v", err)
+	}
+	return nil
+}
+
+func successionKey(ctx contractapi.TransactionContextInterface, successionID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("succession", []string{successionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create succession key: %v", err)
+	}
+	return key, nil
+}
+
+func objectionKey(ctx contractapi.TransactionContextInterface, successionID string, objectionID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("objection", []string{successionID, objectionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create objection key: %v", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInheritShares(t *testing.T) {
+	seller := OwnerShare{OwnerID: "seller", OwnerMSP: "Org1MSP", ShareBasisPoints: fullShare}
+	tests := []struct {
+		name     string
+		shares   []OwnerShare
+		deceased string
+		heirs    []OwnerShare
+		want     []OwnerShare
+		wantErr  string
+	}{
+		{
+			name:     "sole owner",
+			shares:   []OwnerShare{seller},
+			deceased: "seller",
+			heirs:    []OwnerShare{{"heir1", "Org2MSP", 6000}, {"heir2", "Org2MSP", 4000}},
+			want:     []OwnerShare{{"heir1", "Org2MSP", 6000}, {"heir2", "Org2MSP", 4000}},
+		},
+		{
+			name:     "only the deceased's share passes and a holder heir merges",
+			shares:   []OwnerShare{{"seller", "Org1MSP", 5000}, {"buyer", "Org2MSP", 5000}},
+			deceased: "seller",
+			heirs:    []OwnerShare{{"buyer", "Org2MSP", 5000}, {"heir1", "Org2MSP", 5000}},
+			want:     []OwnerShare{{"buyer", "Org2MSP", 7500}, {"heir1", "Org2MSP", 2500}},
+		},
+		{
+			name:     "rounding goes to the first heir",
+			shares:   []OwnerShare{{"seller", "Org1MSP", 6667}, {"buyer", "Org2MSP", 3333}},
+			deceased: "buyer",
+			heirs:    []OwnerShare{{"heir1", "Org2MSP", 5000}, {"heir2", "Org2MSP", 5000}},
+			want:     []OwnerShare{{"seller", "Org1MSP", 6667}, {"heir1", "Org2MSP", 1667}, {"heir2", "Org2MSP", 1666}},
+		},
+		{
+			name:     "deceased is not a holder",
+			shares:   []OwnerShare{seller},
+			deceased: "buyer",
+			heirs:    []OwnerShare{{"heir1", "Org2MSP", fullShare}},
+			wantErr:  "buyer is not a holder of the land",
+		},
+		{
+			name:     "deceased among their heirs",
+			shares:   []OwnerShare{seller},
+			deceased: "seller",
+			heirs:    []OwnerShare{{"seller", "Org1MSP", 5000}, {"heir1", "Org2MSP", 5000}},
+			wantErr:  "cannot be listed among their heirs",
+		},
+		{
+			name:     "heir would inherit nothing",
+			shares:   []OwnerShare{{"seller", "Org1MSP", 9999}, {"buyer", "Org2MSP", 1}},
+			deceased: "buyer",
+			heirs:    []OwnerShare{{"heir1", "Org2MSP", 5000}, {"heir2", "Org2MSP", 5000}},
+			wantErr:  "heir heir2 would inherit less than one basis point",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := inheritShares(test.shares, test.deceased, test.heirs)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected an error containing %q, got %+v, %v", test.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// initiateSuccession has the registry open a succession passing the seller's
+// land to one heir
+func (l *testLedger) initiateSuccession(landID string, successionID string) {
+	l.t.Helper()
+	heirs, err := json.Marshal([]OwnerShare{{OwnerID: "heir1", OwnerMSP: "Org2MSP", ShareBasisPoints: fullShare}})
+	l.must(err)
+	contract := &LandContract{}
+	l.must(contract.InitiateSuccession(l.as(l.registrar, nil), landID, successionID, "seller", "deathcert", "probate", string(heirs)))
+}
+
+func TestSuccessionObjectionWindow(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	record := &BuyerOwnership{OwnerID: "seller", BuyerName: "Seller", Aadhar: "111122223333", LandID: "L1", DocumentHash: "sale"}
+	l.must(putOwnershipRecord(l.as(l.registrar, nil), record))
+
+	l.initiateSuccession("L1", "S1")
+	if land := l.land("L1"); land.Status != StatusDelisted || land.SuccessionID != "S1" {
+		t.Fatalf("land is %s with succession %q, want Delisted with S1", land.Status, land.SuccessionID)
+	}
+	l.mustFail(l.relist("L1", nil), "has succession S1 pending")
+
+	presented, err := json.Marshal([]*BuyerOwnership{record})
+	l.must(err)
+	details, err := json.Marshal([]CoOwner{{OwnerID: "heir1", Name: "Heir", Aadhar: "444455556666"}})
+	l.must(err)
+	transient := map[string][]byte{"ownershipRecords": presented, "holderDetails": details}
+
+	l.mustFail(contract.FinalizeSuccession(l.as(l.registrar, transient), "S1"), "is still open")
+	l.must(contract.RaiseObjection(l.as(l.buyer, nil), "S1", "X1", "grounds"))
+
+	l.advance(31 * 24 * time.Hour)
+	l.mustFail(contract.RaiseObjection(l.as(l.buyer, nil), "S1", "X2", "grounds"), "has ended")
+	l.mustFail(contract.FinalizeSuccession(l.as(l.registrar, transient), "S1"), "has objection X1 pending")
+	l.must(contract.ResolveObjection(l.as(l.registrar, nil), "S1", "X1", false))
+
+	l.mustFail(contract.FinalizeSuccession(l.as(l.registrar, nil), "S1"), "ownership record of land L1 missing")
+	l.mustFail(contract.FinalizeSuccession(l.as(l.registrar, map[string][]byte{"ownershipRecords": presented}), "S1"), "name and aadhar of holder heir1 missing")
+	l.must(contract.FinalizeSuccession(l.as(l.registrar, transient), "S1"))
+
+	land := l.land("L1")
+	if land.Owner != "heir1" || land.OwnerMSP != "Org2MSP" || land.SuccessionID != "" {
+		t.Fatalf("land owned by %s (%s) with succession %q after finalizing", land.Owner, land.OwnerMSP, land.SuccessionID)
+	}
+	var inherited BuyerOwnership
+	l.must(json.Unmarshal(l.privateJSON(collectionBuyerLandRegistry, "L1"), &inherited))
+	if inherited.OwnerID != "heir1" || inherited.BuyerName != "Heir" || inherited.DocumentHash != "probate" {
+		t.Fatalf("unexpected ownership record after succession: %+v", inherited)
+	}
+	if events := l.stub.events; events[len(events)-1] != EventSuccessionFinalized {
+		t.Fatalf("last event is %s, want %s", events[len(events)-1], EventSuccessionFinalized)
+	}
+	l.mustFail(contract.FinalizeSuccession(l.as(l.registrar, transient), "S1"), "succession S1 is Finalized")
+}
+
+func TestSuccessionUpheldObjectionReleasesLand(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+	l.initiateSuccession("L1", "S1")
+	l.must(contract.RaiseObjection(l.as(l.buyer, nil), "S1", "X1", "grounds"))
+	l.must(contract.ResolveObjection(l.as(l.registrar, nil), "S1", "X1", true))
+
+	succession, err := contract.GetSuccession(l.as(l.registrar, nil), "S1")
+	l.must(err)
+	if succession.Status != SuccessionRejected {
+		t.Fatalf("succession is %s, want %s", succession.Status, SuccessionRejected)
+	}
+	if land := l.land("L1"); land.Owner != "seller" || land.SuccessionID != "" {
+		t.Fatalf("land owned by %s with succession %q after rejection", land.Owner, land.SuccessionID)
+	}
+	l.initiateSuccession("L1", "S2")
+}
+
+func TestSuccessionRejectsUnknownHeirOrg(t *testing.T) {
+	l := newTestLedger(t)
+	contract := &LandContract{}
+	l.listLand("L1")
+
+	heirs, err := json.Marshal([]OwnerShare{
+		{OwnerID: "heir1", OwnerMSP: "Org2MSP", ShareBasisPoints: 5000},
+		{OwnerID: "heir2", OwnerMSP: "Org2MPS", ShareBasisPoints: 5000},
+	})
+	l.must(err)
+	l.mustFail(contract.InitiateSuccession(l.as(l.registrar, nil), "L1", "S1", "seller", "deathcert", "probate", string(heirs)), "MSP Org2MPS is not an org of the seller or buyer party")
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+package contracts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Area units accepted on a Land
+const (
+	UnitSqft    = "sqft"
+	UnitSqm     = "sqm"
+	UnitAcre    = "acre"
+	UnitHectare = "hectare"
+	UnitCent    = "cent"
+	UnitGuntha  = "guntha"
+)
+
+// sqmPerUnit converts each area unit to square metres
+var sqmPerUnit = map[string]float64{
+	UnitSqft:    0.09290304,
+	UnitSqm:     1,
+	UnitAcre:    4046.8564224,
+	UnitHectare: 10000,
+	UnitCent:    40.468564224, // 1/100 acre
+	UnitGuntha:  101.17141056, // 1/40 acre
+}
+
+// areaInSqm normalizes an area to square metres, rounded to four decimal places
+func areaInSqm(area float64, unit string) (float64, error) {
+	factor, ok := sqmPerUnit[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown area unit %q, expected one of sqft, sqm, acre, hectare, cent, guntha", unit)
+	}
+	if math.IsNaN(area) || math.IsInf(area, 0) || area <= 0 {
+		return 0, fmt.Errorf("area must be a positive number")
+	}
+	return math.Round(area*factor*10000) / 10000, nil
+}
+
+// setLandArea validates and stores an area with its normalized value
+func setLandArea(land *Land, area float64, unit string) error {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	sqm, err := areaInSqm(area, unit)
+	if err != nil {
+		return err
+	}
+
+	land.Area = area
+	land.AreaUnit = unit
+	land.AreaSqm = sqm
+	return nil
+}
+
+// setLandPrice validates and stores a price in minor currency units (paise)
+func setLandPrice(land *Land, priceMinor int64) error {
+	if priceMinor <= 0 {
+		return fmt.Errorf("sellingPrice must be a positive amount in minor currency units")
+	}
+
+	land.PriceMinor = priceMinor
+	return nil
+}
+
+// formatArea renders an area for certificates, e.g. "2.5 acre"
+func formatArea(land *Land) string {
+	return fmt.Sprintf("%g %s", land.Area, land.AreaUnit)
+}
+
+// formatPrice renders minor currency units as a decimal amount, e.g. 150000050 -> "1500000.50"
+func formatPrice(priceMinor int64) string {
+	return fmt.Sprintf("%d.%02d", priceMinor/100, priceMinor%100)
+}
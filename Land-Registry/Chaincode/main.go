@@ -9,8 +9,10 @@ import (
 
 func main() {
 	LandContract := new(contracts.LandContract)
+	AuctionContract := new(contracts.AuctionContract)
 
-	chaincode, err := contractapi.NewChaincode(LandContract)
+	// LandContract stays the default, so existing callers need no contract name
+	chaincode, err := contractapi.NewChaincode(LandContract, AuctionContract)
 
 	if err != nil {
 		log.Panicf("Could not create chaincode : %v", err)
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookie   = "session"
+	sessionLifetime = 8 * time.Hour
+)
+
+// sessionClaims is the payload of the HS256 JWT handed out at login
+type sessionClaims struct {
+	Subject   string `json:"sub"`
+	Org       string `json:"org"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var (
+	jwtSecret []byte
+	jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+// setupAuth opens the wallet and users file and picks the JWT signing key. Without a
+// configured secret a random one is used, so sessions end when the server restarts.
+func setupAuth() error {
+	var err error
+	if userWallet, err = newWallet(settings.WalletPath); err != nil {
+		return err
+	}
+	if users, err = loadUserStore(settings.UsersPath); err != nil {
+		return err
+	}
+
+	if settings.JWTSecret != "" {
+		jwtSecret = []byte(settings.JWTSecret)
+		return nil
+	}
+	jwtSecret = make([]byte, 32)
+	if _, err := rand.Read(jwtSecret); err != nil {
+		return fmt.Errorf("failed to generate session key: %w", err)
+	}
+	return nil
+}
+
+func issueToken(user *User, now time.Time) (string, time.Time, error) {
+	expires := now.Add(sessionLifetime)
+	payload, err := json.Marshal(sessionClaims{
+		Subject:   user.Username,
+		Org:       user.Org,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signToken(unsigned), expires, nil
+}
+
+func signToken(unsigned string) string {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func parseToken(token string, now time.Time) (*sessionClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, errors.New("malformed token")
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signToken(parts[0]+"."+parts[1]))) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token")
+	}
+	var claims sessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("malformed token")
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, errors.New("session has expired")
+	}
+	return &claims, nil
+}
+
+// requireUser accepts a session from the Authorization header or the session cookie
+// (EventSource cannot set headers) and makes the user available to the handler
+func requireUser(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		token, _ = c.Cookie(sessionCookie)
+	}
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Login required"})
+		return
+	}
+
+	claims, err := parseToken(token, time.Now())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := users.Get(claims.Subject)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unknown user"})
+		return
+	}
+
+	c.Set("user", user)
+	c.Next()
+}
+
+// requireAdmin lets only admin users through; use after requireUser
+func requireAdmin(c *gin.Context) {
+	if currentUser(c).Role != "admin" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Only admins can manage identities"})
+		return
+	}
+	c.Next()
+}
+
+// currentUser is the logged-in user; only valid behind requireUser
+func currentUser(c *gin.Context) *User {
+	return c.MustGet("user").(*User)
+}
+
+func login(c *gin.Context) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, ok := users.Authenticate(body.Username, body.Password)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+
+	token, expires, err := issueToken(user, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"expiresAt": expires.UTC().Format(time.RFC3339),
+		"username":  user.Username,
+		"org":       user.Org,
+		"role":      user.Role,
+	})
+}
+
+func logout(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"sampleapp/fabricca"
+)
+
+// renewBefore is how long before expiry a wallet certificate is re-enrolled
+const renewBefore = 30 * 24 * time.Hour
+
+// caAttributes are the registration attributes put into every enrollment certificate
+var caAttributes = []fabricca.AttributeRequest{
+	{Name: "role", Optional: true},
+	{Name: "org", Optional: true},
+	{Name: "officerID", Optional: true},
+}
+
+// caClient returns the Fabric CA client for an org
+func caClient(org string) (*fabricca.Client, error) {
+	orgProfile, ok := profile[org]
+	if !ok {
+		return nil, fmt.Errorf("unknown org %q", org)
+	}
+	if orgProfile.CAURL == "" {
+		return nil, fmt.Errorf("no Fabric CA is configured for %s", org)
+	}
+
+	if orgProfile.CATLSCertPath == "" {
+		return fabricca.New(orgProfile.CAURL, orgProfile.CAName, nil), nil
+	}
+	caTLSCert, err := loadCertificate(orgProfile.CATLSCertPath)
+	if err != nil {
+		return nil, err
+	}
+	return fabricca.New(orgProfile.CAURL, orgProfile.CAName, caTLSCert), nil
+}
+
+// caSigner turns a wallet identity into a signer for authenticated CA requests
+func (id *walletIdentity) caSigner() (*fabricca.Signer, error) {
+	privateKey, err := identity.PrivateKeyFromPEM([]byte(id.Credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+	return &fabricca.Signer{Certificate: []byte(id.Credentials.Certificate), PrivateKey: signer}, nil
+}
+
+// registrarFor loads the wallet identity an org uses to register and revoke users
+func registrarFor(org string) (*fabricca.Signer, error) {
+	label := profile[org].CARegistrar
+	if label == "" {
+		return nil, fmt.Errorf("no CA registrar is configured for %s", org)
+	}
+	registrar, err := userWallet.Get(label)
+	if err != nil {
+		return nil, err
+	}
+	return registrar.caSigner()
+}
+
+// storeEnrollment puts a CA enrollment into the wallet under label
+func storeEnrollment(label, org string, enrollment *fabricca.Enrollment) (*walletIdentity, error) {
+	id := &walletIdentity{Type: "X.509", Version: 1, MSPID: profile[org].MSPID, Org: org}
+	id.Credentials.Certificate = string(enrollment.Certificate)
+	id.Credentials.PrivateKey = string(enrollment.PrivateKey)
+
+	if err := userWallet.Put(label, id); err != nil {
+		return nil, err
+	}
+	gateways.Forget(label)
+	return id, nil
+}
+
+// respondCAError answers with the CA's own messages, keeping authentication failures apart
+func respondCAError(c *gin.Context, err error) {
+	var caErr *fabricca.Error
+	if !errors.As(err, &caErr) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusBadGateway
+	switch caErr.StatusCode {
+	case http.StatusUnauthorized:
+		status = http.StatusUnauthorized
+	case http.StatusForbidden:
+		status = http.StatusForbidden
+	case http.StatusBadRequest:
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{"error": caErr.Error(), "kind": "ca"})
+}
+
+// Admin - Register a new seller, buyer or officer with the admin's org CA. The returned
+// secret is handed to the person, who enrolls with it.
+func registerIdentity(c *gin.Context) {
+	var body struct {
+		EnrollmentID string `json:"enrollmentID"`
+		Secret       string `json:"secret"` // generated by the CA when empty
+		Role         string `json:"role"`
+		OfficerID    string `json:"officerID"`
+		Affiliation  string `json:"affiliation"`
+	}
+	if err := c.BindJSON(&body); err != nil || body.EnrollmentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if !userRoles[body.Role] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return
+	}
+
+	org := currentUser(c).Org
+	ca, err := caClient(org)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	registrar, err := registrarFor(org)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	attributes := []fabricca.Attribute{
+		{Name: "role", Value: body.Role, ECert: true},
+		{Name: "org", Value: org, ECert: true},
+	}
+	if body.OfficerID != "" {
+		attributes = append(attributes, fabricca.Attribute{Name: "officerID", Value: body.OfficerID, ECert: true})
+	}
+
+	secret, err := ca.Register(c.Request.Context(), registrar, &fabricca.RegistrationRequest{
+		Name:        body.EnrollmentID,
+		Type:        "client",
+		Secret:      body.Secret,
+		Affiliation: body.Affiliation,
+		Attributes:  attributes,
+	})
+	if err != nil {
+		respondCAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enrollmentID": body.EnrollmentID, "secret": secret, "org": org})
+}
+
+// Anyone - Enroll with a registration secret; the identity goes into the wallet and a
+// login account with the chosen password is created
+func enrollIdentity(c *gin.Context) {
+	var body struct {
+		Org          string `json:"org"`
+		EnrollmentID string `json:"enrollmentID"`
+		Secret       string `json:"secret"`
+		Password     string `json:"password"`
+	}
+	if err := c.BindJSON(&body); err != nil || body.EnrollmentID == "" || body.Secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if len(body.Password) < 8 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 8 characters"})
+		return
+	}
+	if _, exists := users.Get(body.EnrollmentID); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	}
+	if _, err := userWallet.Get(body.EnrollmentID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Identity already exists in the wallet"})
+		return
+	}
+
+	ca, err := caClient(body.Org)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	enrollment, err := ca.Enroll(c.Request.Context(), body.EnrollmentID, body.Secret, caAttributes)
+	if err != nil {
+		respondCAError(c, err)
+		return
+	}
+
+	attributes, err := fabricca.Attributes(enrollment.Certificate)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := storeEnrollment(body.EnrollmentID, body.Org, enrollment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	hash, err := hashPassword(body.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user := &User{
+		Username:     body.EnrollmentID,
+		Org:          body.Org,
+		Identity:     body.EnrollmentID,
+		Role:         attributes["role"],
+		PasswordHash: hash,
+	}
+	if err := users.Put(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"username": user.Username, "org": user.Org, "role": user.Role})
+}
+
+// Any user - Re-enroll their own identity; an admin may name another user of their org
+func reenrollIdentity(c *gin.Context) {
+	var body struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user := currentUser(c)
+	if body.Username != "" && body.Username != user.Username {
+		other, ok := users.Get(body.Username)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if user.Role != "admin" || other.Org != user.Org {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only an admin of the same org can re-enroll another user"})
+			return
+		}
+		user = other
+	}
+
+	expires, err := reenroll(c.Request.Context(), user.Identity)
+	if err != nil {
+		respondCAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"username": user.Username, "expiresAt": expires.UTC().Format(time.RFC3339)})
+}
+
+// reenroll renews a wallet identity's certificate and key with its org CA
+func reenroll(ctx context.Context, label string) (time.Time, error) {
+	current, err := userWallet.Get(label)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ca, err := caClient(current.Org)
+	if err != nil {
+		return time.Time{}, err
+	}
+	signer, err := current.caSigner()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	enrollment, err := ca.Reenroll(ctx, signer, caAttributes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if _, err := storeEnrollment(label, current.Org, enrollment); err != nil {
+		return time.Time{}, err
+	}
+	return fabricca.Expiry(enrollment.Certificate)
+}
+
+// Admin - Revoke a user of the admin's org: their certificates are revoked at the CA
+// and their identity and login are removed
+func revokeIdentity(c *gin.Context) {
+	var body struct {
+		Username string `json:"username"`
+		Reason   string `json:"reason"`
+	}
+	if err := c.BindJSON(&body); err != nil || body.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	admin := currentUser(c)
+	user, ok := users.Get(body.Username)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Org != admin.Org {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only an admin of the same org can revoke a user"})
+		return
+	}
+
+	ca, err := caClient(user.Org)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	registrar, err := registrarFor(user.Org)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := userWallet.Get(user.Identity)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	enrollmentID, err := id.enrollmentID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ca.Revoke(c.Request.Context(), registrar, &fabricca.RevocationRequest{
+		Name:   enrollmentID,
+		Reason: body.Reason,
+	}); err != nil {
+		respondCAError(c, err)
+		return
+	}
+
+	gateways.Forget(user.Identity)
+	if err := userWallet.Remove(user.Identity); err != nil {
+		log.Printf("revoked %s but could not remove the wallet identity: %v", user.Username, err)
+	}
+	if err := users.Remove(user.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"username": user.Username, "revoked": true})
+}
+
+// enrollmentID is the CA identity name, the certificate's common name
+func (id *walletIdentity) enrollmentID() (string, error) {
+	certificate, err := identity.CertificateFromPEM([]byte(id.Credentials.Certificate))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return certificate.Subject.CommonName, nil
+}
+
+// renewIdentities re-enrolls wallet identities whose certificates expire within
+// renewBefore, checking twice a day until ctx ends
+func renewIdentities(ctx context.Context) {
+	ticker := time.NewTicker(12 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		renewExpiring(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func renewExpiring(ctx context.Context) {
+	labels, err := userWallet.Labels()
+	if err != nil {
+		log.Printf("identity renewal: %v", err)
+		return
+	}
+
+	for _, label := range labels {
+		id, err := userWallet.Get(label)
+		if err != nil || profile[id.Org].CAURL == "" {
+			continue
+		}
+		expires, err := fabricca.Expiry([]byte(id.Credentials.Certificate))
+		if err != nil || time.Until(expires) > renewBefore {
+			continue
+		}
+
+		if expires, err = reenroll(ctx, label); err != nil {
+			log.Printf("identity renewal: failed to re-enroll %s: %v", label, err)
+			continue
+		}
+		log.Printf("identity renewal: re-enrolled %s, now valid until %s", label, expires.Format(time.RFC3339))
+	}
+}
+
+// enrollCommand enrolls an identity that was registered outside the backend, such as
+// the CA bootstrap admin used as an org's registrar:
+//
+//	go run . enroll -org org1 -id admin -secret adminpw -identity org1-admin
+func enrollCommand(args []string) error {
+	flags := flag.NewFlagSet("enroll", flag.ContinueOnError)
+	org := flags.String("org", "", "org whose CA to enroll with, e.g. org1")
+	enrollmentID := flags.String("id", "", "enrollment ID")
+	secret := flags.String("secret", "", "enrollment secret")
+	label := flags.String("identity", "", "wallet label, default the enrollment ID")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *enrollmentID == "" || *secret == "" {
+		return errors.New("-id and -secret are required")
+	}
+	if *label == "" {
+		*label = *enrollmentID
+	}
+
+	ca, err := caClient(*org)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	enrollment, err := ca.Enroll(ctx, *enrollmentID, *secret, caAttributes)
+	if err != nil {
+		return err
+	}
+	if _, err := storeEnrollment(*label, *org, enrollment); err != nil {
+		return err
+	}
+
+	fmt.Printf("enrolled %s into wallet as %s\n", *enrollmentID, *label)
+	return nil
+}
@@ -2,13 +2,15 @@ package main
 
 import (
 	"fmt"
-	"time"
+	"log"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 )
 
+// submitTxnFn runs a transaction signed by the wallet identity with the given label,
+// normally the logged-in user's. Failures come back as *TxnError.
 func submitTxnFn(
-	organization string,
+	identityLabel string,
 	channelName string,
 	chaincodeName string,
 	contractName string,
@@ -16,65 +18,91 @@ func submitTxnFn(
 	privateData map[string][]byte,
 	txnName string,
 	args ...string,
-) string {
+) (string, error) {
 
-	orgProfile := profile[organization]
-
-	clientConn := newGrpcConnection(orgProfile.TLSCertPath, orgProfile.GatewayPeer, orgProfile.PeerEndpoint)
-	defer clientConn.Close()
-
-	id := newIdentity(orgProfile.CertPath, orgProfile.MSPID)
-	sign := newSign(orgProfile.KeyDirectory)
-
-	gw, err := client.Connect(
-		id,
-		client.WithSign(sign),
-		client.WithClientConnection(clientConn),
-		client.WithEvaluateTimeout(5*time.Second),
-		client.WithEndorseTimeout(15*time.Second),
-		client.WithSubmitTimeout(5*time.Second),
-		client.WithCommitStatusTimeout(1*time.Minute),
-	)
+	gw, err := gateways.ForIdentity(identityLabel)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	defer gw.Close()
 
 	network := gw.GetNetwork(channelName)
 	contract := network.GetContractWithName(chaincodeName, contractName)
 
-	fmt.Printf("\n--> Submitting Transaction: %s\n", txnName)
-
+	kind := "submit"
+	options := []client.ProposalOption{client.WithArguments(args...)}
 	switch txnType {
-
 	case "invoke":
-		result, err := contract.SubmitTransaction(txnName, args...)
-		if err != nil {
-			panic(fmt.Errorf("submit failed: %w", err))
-		}
-		return fmt.Sprintf("*** Transaction Success: %s\n", result)
-
 	case "query":
-		result, err := contract.EvaluateTransaction(txnName, args...)
+		kind = "evaluate"
+	case "private":
+		options = append(options, client.WithTransient(privateData))
+	default:
+		return "", fmt.Errorf("invalid transaction type %q", txnType)
+	}
+
+	proposal, err := contract.NewProposal(txnName, options...)
+	if err != nil {
+		return "", newTxnError(kind, err)
+	}
+
+	// Arguments and transient data may carry personal details, so only the
+	// transaction's identity and outcome are logged
+	outcome := "ok"
+	defer func() {
+		log.Printf("%s transaction %s %s: %s", txnType, txnName, proposal.TransactionID(), outcome)
+	}()
+	fail := func(stage string, err error) (string, error) {
+		txnErr := newTxnError(stage, err)
+		outcome = txnErr.Kind + " failed"
+		return "", txnErr
+	}
+
+	if txnType == "query" {
+		result, err := proposal.Evaluate()
 		if err != nil {
-			panic(fmt.Errorf("query failed: %w", err))
+			return fail("evaluate", err)
 		}
 		if isByteSliceEmpty(result) {
-			return string(result)
+			return string(result), nil
 		}
 		return formatJSON(result)
+	}
 
-	case "private":
-		result, err := contract.Submit(
-			txnName,
-			client.WithArguments(args...),
-			client.WithTransient(privateData),
-		)
-		if err != nil {
-			panic(fmt.Errorf("private submit failed: %w", err))
-		}
-		return fmt.Sprintf("*** Private Transaction Committed:\n%s\n", result)
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		return fail("endorse", err)
+	}
+	commit, err := transaction.Submit()
+	if err != nil {
+		return fail("submit", err)
+	}
+	status, err := commit.Status()
+	if err != nil {
+		return fail("commitStatus", err)
+	}
+	outcome = status.Code.String()
+	if !status.Successful {
+		return "", newCommitError(status.TransactionID, status.Code)
 	}
 
-	return "Invalid transaction type"
+	if txnType == "private" {
+		return fmt.Sprintf("*** Private Transaction Committed:\n%s\n", transaction.Result()), nil
+	}
+	return fmt.Sprintf("*** Transaction Success: %s\n", transaction.Result()), nil
+}
+
+// evaluateAsOrg evaluates a query signed by the org's own service identity, for
+// endpoints that serve callers without a login
+func evaluateAsOrg(org string, txnName string, args ...string) (string, error) {
+	gw, err := gateways.Get(org)
+	if err != nil {
+		return "", err
+	}
+
+	contract := gw.GetNetwork(settings.Channel).GetContractWithName(settings.Chaincode, "LandContract")
+	result, err := contract.EvaluateTransaction(txnName, args...)
+	if err != nil {
+		return "", newTxnError("evaluate", err)
+	}
+	return string(result), nil
 }
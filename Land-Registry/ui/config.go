@@ -0,0 +1,310 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings is the backend's runtime configuration. It is read from a YAML or JSON
+// file (LAND_REGISTRY_CONFIG, default ./config.yaml) and then overridden by
+// LAND_REGISTRY_* environment variables.
+type Settings struct {
+	Channel       string            `yaml:"channel"`
+	Chaincode     string            `yaml:"chaincode"`
+	ListenAddress string            `yaml:"listenAddress"`
+	TestNetwork   string            `yaml:"testNetwork"` // fabric-samples/test-network, used when no orgs are configured
+	WalletPath    string            `yaml:"walletPath"`  // users' enrolled identities
+	UsersPath     string            `yaml:"usersPath"`   // login accounts
+	JWTSecret     string            `yaml:"jwtSecret"`   // session signing key; random per run when empty
+	VerifierOrg   string            `yaml:"verifierOrg"` // org whose identity answers public verification; first org when empty
+	Orgs          map[string]Config `yaml:"orgs"`
+}
+
+var settings = Settings{
+	Channel:       "autochannel",
+	Chaincode:     "Land-Registry",
+	ListenAddress: "localhost:3001",
+	TestNetwork:   "../../fabric-samples/test-network",
+	WalletPath:    "wallet",
+	UsersPath:     "users.json",
+}
+
+const envPrefix = "LAND_REGISTRY_"
+
+// loadSettings reads the config file and environment, fills org profiles from any
+// connection profiles, and checks every org's certificates and keys. All problems
+// are reported together.
+func loadSettings() error {
+	path, explicit := os.LookupEnv(envPrefix + "CONFIG")
+	if !explicit {
+		path = "config.yaml"
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	case explicit || !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	overrideString(&settings.Channel, "CHANNEL")
+	overrideString(&settings.Chaincode, "CHAINCODE")
+	overrideString(&settings.ListenAddress, "LISTEN_ADDRESS")
+	overrideString(&settings.TestNetwork, "TEST_NETWORK")
+	overrideString(&settings.WalletPath, "WALLET_PATH")
+	overrideString(&settings.UsersPath, "USERS_PATH")
+	overrideString(&settings.JWTSecret, "JWT_SECRET")
+	overrideString(&settings.VerifierOrg, "VERIFIER_ORG")
+
+	if settings.Orgs == nil {
+		settings.Orgs = map[string]Config{}
+	}
+	if orgs := os.Getenv(envPrefix + "ORGS"); orgs != "" {
+		for _, name := range strings.Split(orgs, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := settings.Orgs[name]; !ok && name != "" {
+				settings.Orgs[name] = Config{}
+			}
+		}
+	}
+	if len(settings.Orgs) == 0 {
+		settings.Orgs = testNetworkProfiles(settings.TestNetwork)
+	}
+
+	var problems []error
+	if settings.Channel == "" {
+		problems = append(problems, fmt.Errorf("channel is not set"))
+	}
+	if settings.Chaincode == "" {
+		problems = append(problems, fmt.Errorf("chaincode is not set"))
+	}
+
+	names := make([]string, 0, len(settings.Orgs))
+	for name := range settings.Orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if settings.VerifierOrg == "" && len(names) > 0 {
+		settings.VerifierOrg = names[0]
+	}
+	if _, ok := settings.Orgs[settings.VerifierOrg]; !ok {
+		problems = append(problems, fmt.Errorf("verifierOrg %q is not a configured org", settings.VerifierOrg))
+	}
+
+	for _, name := range names {
+		org := settings.Orgs[name]
+		overrideOrg(name, &org)
+		if org.ConnectionProfile != "" {
+			if err := applyConnectionProfile(&org); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+		org.resolvePaths()
+		problems = append(problems, org.validate(name)...)
+		settings.Orgs[name] = org
+	}
+
+	if len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+
+	profile = settings.Orgs
+	return nil
+}
+
+// overrideString replaces a setting with LAND_REGISTRY_<name> when that variable is set
+func overrideString(value *string, name string) {
+	if env, ok := os.LookupEnv(envPrefix + name); ok {
+		*value = env
+	}
+}
+
+// overrideOrg applies LAND_REGISTRY_<ORG>_<FIELD> variables, e.g. LAND_REGISTRY_ORG1_PEER_ENDPOINT
+func overrideOrg(name string, org *Config) {
+	prefix := strings.ToUpper(name) + "_"
+	overrideString(&org.CryptoPath, prefix+"CRYPTO_PATH")
+	overrideString(&org.CertPath, prefix+"CERT_PATH")
+	overrideString(&org.KeyDirectory, prefix+"KEY_PATH")
+	overrideString(&org.TLSCertPath, prefix+"TLS_CERT_PATH")
+	overrideString(&org.PeerEndpoint, prefix+"PEER_ENDPOINT")
+	overrideString(&org.GatewayPeer, prefix+"GATEWAY_PEER")
+	overrideString(&org.MSPID, prefix+"MSP_ID")
+	overrideString(&org.ConnectionProfile, prefix+"CONNECTION_PROFILE")
+	overrideString(&org.CAURL, prefix+"CA_URL")
+	overrideString(&org.CAName, prefix+"CA_NAME")
+	overrideString(&org.CATLSCertPath, prefix+"CA_TLS_CERT_PATH")
+	overrideString(&org.CARegistrar, prefix+"CA_REGISTRAR")
+}
+
+// resolvePaths makes relative certificate and key paths relative to CryptoPath
+func (c *Config) resolvePaths() {
+	if c.CryptoPath == "" {
+		return
+	}
+	for _, p := range []*string{&c.CertPath, &c.KeyDirectory, &c.TLSCertPath, &c.CATLSCertPath} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(c.CryptoPath, *p)
+		}
+	}
+}
+
+// validate reports everything missing or unreadable in an org profile
+func (c *Config) validate(name string) []error {
+	var problems []error
+	missing := func(field string) {
+		problems = append(problems, fmt.Errorf("%s: %s is not set", name, field))
+	}
+
+	if c.MSPID == "" {
+		missing("mspID")
+	}
+	if c.PeerEndpoint == "" {
+		missing("peerEndpoint")
+	}
+	if c.GatewayPeer == "" {
+		missing("gatewayPeer")
+	}
+
+	if c.CertPath == "" {
+		missing("certPath")
+	} else if _, err := loadCertificate(c.CertPath); err != nil {
+		problems = append(problems, fmt.Errorf("%s: certPath %s: %w", name, c.CertPath, err))
+	}
+
+	if c.KeyDirectory == "" {
+		missing("keyPath")
+	} else if _, err := newSign(c.KeyDirectory); err != nil {
+		problems = append(problems, fmt.Errorf("%s: keyPath %s: %w", name, c.KeyDirectory, err))
+	}
+
+	if c.TLSCertPath == "" && c.tlsCertPEM == nil {
+		missing("tlsCertPath")
+	} else if _, err := c.tlsCertificate(); err != nil {
+		source := "tlsCertPath " + c.TLSCertPath
+		if c.tlsCertPEM != nil {
+			source = "tlsCACerts in " + c.ConnectionProfile
+		}
+		problems = append(problems, fmt.Errorf("%s: %s: %w", name, source, err))
+	}
+
+	// The CA is optional; without it users can only be added with add-user
+	if strings.HasPrefix(c.CAURL, "https://") {
+		if c.CATLSCertPath == "" {
+			missing("caTLSCertPath")
+		} else if _, err := loadCertificate(c.CATLSCertPath); err != nil {
+			problems = append(problems, fmt.Errorf("%s: caTLSCertPath %s: %w", name, c.CATLSCertPath, err))
+		}
+	}
+
+	return problems
+}
+
+// tlsCertificate returns the gateway peer's TLS CA certificate, inline or from file
+func (c *Config) tlsCertificate() (*x509.Certificate, error) {
+	if c.tlsCertPEM != nil {
+		return identity.CertificateFromPEM(c.tlsCertPEM)
+	}
+	return loadCertificate(c.TLSCertPath)
+}
+
+// connectionProfile is the part of a Fabric common connection profile (CCP) the backend uses
+type connectionProfile struct {
+	Client struct {
+		Organization string `yaml:"organization"`
+	} `yaml:"client"`
+	Organizations map[string]struct {
+		MSPID string   `yaml:"mspid"`
+		Peers []string `yaml:"peers"`
+	} `yaml:"organizations"`
+	Peers map[string]struct {
+		URL        string `yaml:"url"`
+		TLSCACerts struct {
+			PEM  pemValue `yaml:"pem"`
+			Path string   `yaml:"path"`
+		} `yaml:"tlsCACerts"`
+		GRPCOptions map[string]interface{} `yaml:"grpcOptions"`
+	} `yaml:"peers"`
+}
+
+// pemValue accepts a PEM given either as one string or as a list of strings
+type pemValue string
+
+func (p *pemValue) UnmarshalYAML(node *yaml.Node) error {
+	var list []string
+	if node.Kind == yaml.SequenceNode {
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*p = pemValue(strings.Join(list, "\n"))
+		return nil
+	}
+	return node.Decode((*string)(p))
+}
+
+// applyConnectionProfile fills mspID, peer endpoint, gateway peer and TLS CA from a CCP
+// file. Values set explicitly in the config or environment take precedence.
+func applyConnectionProfile(c *Config) error {
+	data, err := os.ReadFile(c.ConnectionProfile)
+	if err != nil {
+		return fmt.Errorf("connectionProfile %s: %w", c.ConnectionProfile, err)
+	}
+
+	var ccp connectionProfile
+	if err := yaml.Unmarshal(data, &ccp); err != nil {
+		return fmt.Errorf("connectionProfile %s: %w", c.ConnectionProfile, err)
+	}
+
+	orgName := ccp.Client.Organization
+	if orgName == "" && len(ccp.Organizations) == 1 {
+		for name := range ccp.Organizations {
+			orgName = name
+		}
+	}
+	org, ok := ccp.Organizations[orgName]
+	if !ok {
+		return fmt.Errorf("connectionProfile %s: cannot tell which organization to use", c.ConnectionProfile)
+	}
+	if len(org.Peers) == 0 {
+		return fmt.Errorf("connectionProfile %s: organization %s lists no peers", c.ConnectionProfile, orgName)
+	}
+
+	peerName := org.Peers[0]
+	peer, ok := ccp.Peers[peerName]
+	if !ok {
+		return fmt.Errorf("connectionProfile %s: peer %s is not defined", c.ConnectionProfile, peerName)
+	}
+
+	if c.MSPID == "" {
+		c.MSPID = org.MSPID
+	}
+	if c.PeerEndpoint == "" {
+		c.PeerEndpoint = strings.TrimPrefix(strings.TrimPrefix(peer.URL, "grpcs://"), "grpc://")
+	}
+	if c.GatewayPeer == "" {
+		c.GatewayPeer = peerName
+		if override, ok := peer.GRPCOptions["ssl-target-name-override"].(string); ok && override != "" {
+			c.GatewayPeer = override
+		}
+	}
+	if c.TLSCertPath == "" {
+		if peer.TLSCACerts.PEM != "" {
+			c.tlsCertPEM = []byte(peer.TLSCACerts.PEM)
+		} else {
+			c.TLSCertPath = peer.TLSCACerts.Path
+		}
+	}
+
+	return nil
+}
@@ -14,37 +14,32 @@ import (
 )
 
 // newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection(tlsCertPath string, gatewayPeer string, peerEndpoint string) *grpc.ClientConn {
-	certificate, err := loadCertificate(tlsCertPath)
-	if err != nil {
-		panic(err)
-	}
-
+func newGrpcConnection(certificate *x509.Certificate, gatewayPeer string, peerEndpoint string) (*grpc.ClientConn, error) {
 	certPool := x509.NewCertPool()
 	certPool.AddCert(certificate)
 	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
 
 	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
 	}
 
-	return connection
+	return connection, nil
 }
 
 // newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity(certPath string, mspID string) *identity.X509Identity {
+func newIdentity(certPath string, mspID string) (*identity.X509Identity, error) {
 	certificate, err := loadCertificate(certPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
- 
+
 	id, err := identity.NewX509Identity(mspID, certificate)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to create identity: %w", err)
 	}
 
-	return id
+	return id, nil
 }
 
 func loadCertificate(filename string) (*x509.Certificate, error) {
@@ -56,39 +51,42 @@ func loadCertificate(filename string) (*x509.Certificate, error) {
 }
 
 // newSign creates a function that generates a digital signature from a message digest using a private key.
-func newSign(keyPath string) identity.Sign {
+func newSign(keyPath string) (identity.Sign, error) {
 	files, err := os.ReadDir(keyPath)
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
+		return nil, fmt.Errorf("failed to read private key directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no private key found in %s", keyPath)
 	}
 	privateKeyPEM, err := os.ReadFile(path.Join(keyPath, files[0].Name()))
 
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
 	}
 
 	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
 	sign, err := identity.NewPrivateKeySign(privateKey)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to create signer: %w", err)
 	}
 
-	return sign
+	return sign, nil
 }
 
 // Format JSON data
-func formatJSON(data []byte) string {
+func formatJSON(data []byte) (string, error) {
 	var prettyJSON bytes.Buffer
 	if err := json.Indent(&prettyJSON, data, "", "  "); err != nil {
-		panic(fmt.Errorf("failed to parse JSON: %w", err))
+		return "", fmt.Errorf("failed to parse JSON: %w", err)
 	}
-	return prettyJSON.String()
+	return prettyJSON.String(), nil
 }
 
 func isByteSliceEmpty(data []byte) bool {
 	return len(data) == 0
-}
\ No newline at end of file
+}
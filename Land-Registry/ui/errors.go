@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TxnError is a failed gateway call, classified so handlers can answer with the right HTTP status
+type TxnError struct {
+	Status       int
+	Kind         string // connection, evaluate, endorse, submit, commitStatus, commit
+	TxID         string
+	Message      string // the chaincode's own message when there is one
+	Endorsements []EndorsementDetail
+	err          error
+}
+
+// EndorsementDetail is what one peer or orderer said about a failed transaction
+type EndorsementDetail struct {
+	Address string `json:"address"`
+	MSPID   string `json:"mspID"`
+	Message string `json:"message"`
+}
+
+func (e *TxnError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Kind, e.err)
+}
+
+func (e *TxnError) Unwrap() error {
+	return e.err
+}
+
+// chaincodeStatuses maps chaincode error messages to HTTP statuses, first match wins
+var chaincodeStatuses = []struct {
+	contains string
+	status   int
+}{
+	{"frozen by court order", http.StatusLocked},
+	{"does not exist", http.StatusNotFound},
+	{"no title documents", http.StatusNotFound},
+	{"has no history", http.StatusNotFound},
+	{"not found", http.StatusNotFound},
+	{"only ", http.StatusForbidden},
+	{"not submitted by its current owner", http.StatusForbidden},
+	{"already", http.StatusConflict},
+	{"has changed owner", http.StatusConflict},
+	{"has not been approved", http.StatusForbidden},
+	{"is approved", http.StatusConflict},
+	{"is not a split request", http.StatusBadRequest},
+	{"is not a merge request", http.StatusBadRequest},
+	{"no winning bid", http.StatusConflict},
+	{"no longer the accepted offer", http.StatusConflict},
+	{"is not listed for sale", http.StatusConflict},
+	{"has no accepted offer", http.StatusConflict},
+	{"has not been verified", http.StatusConflict},
+	{"has expired", http.StatusConflict},
+	{"does not match", http.StatusConflict},
+	{"is not the accepted offer", http.StatusConflict},
+	{"is pending", http.StatusConflict},
+	{"is accepted", http.StatusConflict},
+	{"is rejected", http.StatusConflict},
+	{"is withdrawn", http.StatusConflict},
+	{"has ended", http.StatusConflict},
+	{"has been retired", http.StatusConflict},
+	{"is listed for sale", http.StatusConflict},
+	{"is in auction", http.StatusConflict},
+	{"has an accepted offer", http.StatusConflict},
+	{"have different owners", http.StatusConflict},
+	{"add up to", http.StatusBadRequest},
+	{"active lien", http.StatusConflict},
+	{"is leased until", http.StatusConflict},
+	{"is terminated", http.StatusConflict},
+	{"has no renewals left", http.StatusConflict},
+	{"is not the current lease", http.StatusConflict},
+	{"pending", http.StatusConflict},
+	{"is finalized", http.StatusConflict},
+	{"is cancelled", http.StatusConflict},
+	{"more than once", http.StatusBadRequest},
+	{"is not an org of the", http.StatusBadRequest},
+	{"have consented to the sale", http.StatusConflict},
+	{"has not consented", http.StatusConflict},
+	{"is not jointly held", http.StatusConflict},
+	{"jointly held; a whole title", http.StatusConflict},
+	{"fewer than", http.StatusBadRequest},
+	{"is not a holder of", http.StatusBadRequest},
+	{"less than one basis point", http.StatusBadRequest},
+	{"among their heirs", http.StatusBadRequest},
+	{"to themselves", http.StatusBadRequest},
+	{"is still open", http.StatusConflict},
+	{"presented", http.StatusBadRequest},
+	{"missing", http.StatusBadRequest},
+	{"required", http.StatusBadRequest},
+	{"must be", http.StatusBadRequest},
+	{"unknown", http.StatusBadRequest},
+	{"invalid", http.StatusBadRequest},
+	{"failed to parse", http.StatusBadRequest},
+	{"error managing parameter", http.StatusBadRequest},
+}
+
+var chaincodeResponse = regexp.MustCompile(`chaincode response \d+, (.*)`)
+
+// newCommitError reports a transaction that was ordered but failed validation
+func newCommitError(txID string, code peer.TxValidationCode) *TxnError {
+	message := fmt.Sprintf("transaction %s failed to commit with status code %d (%s)", txID, int32(code), code)
+	txnErr := &TxnError{
+		Kind:    "commit",
+		Status:  http.StatusInternalServerError,
+		Message: message,
+		TxID:    txID,
+		err:     errors.New(message),
+	}
+	if code == peer.TxValidationCode_MVCC_READ_CONFLICT || code == peer.TxValidationCode_PHANTOM_READ_CONFLICT {
+		txnErr.Status = http.StatusConflict
+	}
+	return txnErr
+}
+
+// newTxnError classifies an error returned by the gateway client
+func newTxnError(kind string, err error) *TxnError {
+	txnErr := &TxnError{Kind: kind, Status: http.StatusInternalServerError, Message: err.Error(), err: err}
+
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		txnErr = newCommitError(commitErr.TransactionID, commitErr.Code)
+		txnErr.Message = err.Error()
+		txnErr.err = err
+		return txnErr
+	}
+
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	switch {
+	case errors.As(err, &endorseErr):
+		txnErr.Kind = "endorse"
+		txnErr.TxID = endorseErr.TransactionID
+	case errors.As(err, &submitErr):
+		txnErr.Kind = "submit"
+		txnErr.TxID = submitErr.TransactionID
+	case errors.As(err, &commitStatusErr):
+		// The transaction may still commit; the caller can't tell, so treat it as unavailable
+		txnErr.Kind = "commitStatus"
+		txnErr.TxID = commitStatusErr.TransactionID
+		txnErr.Status = http.StatusServiceUnavailable
+		return txnErr
+	}
+
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		return txnErr
+	}
+
+	txnErr.Message = grpcStatus.Message()
+	for _, detail := range grpcStatus.Details() {
+		if errDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			txnErr.Endorsements = append(txnErr.Endorsements, EndorsementDetail{
+				Address: errDetail.GetAddress(),
+				MSPID:   errDetail.GetMspId(),
+				Message: errDetail.GetMessage(),
+			})
+		}
+	}
+
+	switch grpcStatus.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted:
+		txnErr.Status = http.StatusServiceUnavailable
+		return txnErr
+	}
+
+	if message, ok := chaincodeMessage(txnErr); ok {
+		txnErr.Message = message
+		txnErr.Status = http.StatusInternalServerError
+		lower := strings.ToLower(message)
+		for _, candidate := range chaincodeStatuses {
+			if strings.Contains(lower, candidate.contains) {
+				txnErr.Status = candidate.status
+				break
+			}
+		}
+	}
+
+	return txnErr
+}
+
+// chaincodeMessage digs the chaincode's error message out of the peer responses
+func chaincodeMessage(txnErr *TxnError) (string, bool) {
+	for _, endorsement := range txnErr.Endorsements {
+		if match := chaincodeResponse.FindStringSubmatch(endorsement.Message); match != nil {
+			return match[1], true
+		}
+	}
+	if match := chaincodeResponse.FindStringSubmatch(txnErr.Message); match != nil {
+		return match[1], true
+	}
+	return "", false
+}
+
+// connectionError wraps a failure to reach or authenticate with an org's gateway
+func connectionError(err error) *TxnError {
+	return &TxnError{Kind: "connection", Status: http.StatusServiceUnavailable, Message: err.Error(), err: err}
+}
+
+// respondError writes a failed call as JSON with its HTTP status
+func respondError(c *gin.Context, err error) {
+	var txnErr *TxnError
+	if !errors.As(err, &txnErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(txnErr.Status, gin.H{
+		"error":        txnErr.Message,
+		"kind":         txnErr.Kind,
+		"txID":         txnErr.TxID,
+		"endorsements": txnErr.Endorsements,
+	})
+}
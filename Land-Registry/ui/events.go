@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// orgEventTypes lists the chaincode events each org's browser clients receive. nil means every event.
+var orgEventTypes = map[string]map[string]bool{
+	"org1": {
+		"LandListed": true, "LandDelisted": true, "LandPriceUpdated": true, "OfferCreated": true,
+		"OfferAccepted": true, "OfferRejected": true, "OfferWithdrawn": true, "OwnershipTransferred": true,
+		"SellerTitleSubmitted": true, "SellerTitleVerified": true, "AuctionStarted": true,
+		"BidCommitted": true, "BidRevealed": true, "AuctionClosed": true, "WinningBidRecorded": true,
+		"LandSplit": true, "LandsMerged": true, "ParcelChangeRequested": true,
+		"ParcelRequestCancelled": true, "LienApproved": true, "LienRegistered": true, "LienReleased": true,
+		"LienholderCertificateUpdated": true, "LandFrozen": true, "LandUnfrozen": true,
+		"LeaseRegistered": true, "LeaseAccepted": true, "LeaseRenewed": true, "LeaseTerminated": true,
+		"SuccessionInitiated": true, "SuccessionObjected": true, "ObjectionResolved": true,
+		"SuccessionCancelled": true, "SuccessionFinalized": true, "SaleConsented": true,
+		"ShareConsented": true, "ShareTransferred": true, "PowerOfAttorneyGranted": true,
+		"PowerOfAttorneyRevoked": true, "LegacyOwnerAssigned": true,
+	},
+	"org2": {
+		"LandListed": true, "LandDelisted": true, "LandPriceUpdated": true, "OfferCreated": true,
+		"OfferAccepted": true, "OfferRejected": true, "OfferWithdrawn": true, "OwnershipTransferred": true,
+		"AuctionStarted": true, "BidCommitted": true, "BidRevealed": true, "AuctionClosed": true,
+		"WinningBidRecorded": true, "LandSplit": true, "LandsMerged": true, "ParcelChangeRequested": true,
+		"ParcelRequestCancelled": true, "LienApproved": true, "LienRegistered": true, "LienReleased": true,
+		"LienholderCertificateUpdated": true, "LandFrozen": true, "LandUnfrozen": true,
+		"LeaseRegistered": true, "LeaseAccepted": true, "LeaseRenewed": true, "LeaseTerminated": true,
+		"SuccessionInitiated": true, "SuccessionObjected": true, "ObjectionResolved": true,
+		"SuccessionCancelled": true, "SuccessionFinalized": true, "SaleConsented": true,
+		"ShareConsented": true, "ShareTransferred": true, "PowerOfAttorneyGranted": true,
+		"PowerOfAttorneyRevoked": true, "LegacyOwnerAssigned": true,
+	},
+	"org3": nil,
+}
+
+// eventHub shares one chaincode event stream for an org among all of its browser clients.
+// The stream starts with the first subscriber and stops when the last one leaves.
+type eventHub struct {
+	org         string
+	mu          sync.Mutex
+	subscribers map[chan *client.ChaincodeEvent]struct{}
+	cancel      context.CancelFunc
+}
+
+var (
+	hubsMu sync.Mutex
+	hubs   = map[string]*eventHub{}
+)
+
+func eventHubFor(org string) *eventHub {
+	hubsMu.Lock()
+	defer hubsMu.Unlock()
+
+	hub, ok := hubs[org]
+	if !ok {
+		hub = &eventHub{org: org, subscribers: map[chan *client.ChaincodeEvent]struct{}{}}
+		hubs[org] = hub
+	}
+	return hub
+}
+
+// subscribe registers a client and returns its event channel and an unsubscribe function.
+// A client that cannot keep up has its channel closed.
+func (h *eventHub) subscribe() (<-chan *client.ChaincodeEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subscribers) == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		h.cancel = cancel
+		go h.run(ctx)
+	}
+
+	events := make(chan *client.ChaincodeEvent, 64)
+	h.subscribers[events] = struct{}{}
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subscribers[events]; ok {
+			delete(h.subscribers, events)
+			close(events)
+		}
+		if len(h.subscribers) == 0 && h.cancel != nil {
+			h.cancel()
+			h.cancel = nil
+		}
+	}
+}
+
+func (h *eventHub) broadcast(event *client.ChaincodeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for events := range h.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(h.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// run keeps the org's event stream open, reconnecting from the last delivered event on failure
+func (h *eventHub) run(ctx context.Context) {
+	checkpoint := new(client.InMemoryCheckpointer)
+	resume := false
+
+	for ctx.Err() == nil {
+		err := h.stream(ctx, checkpoint, &resume)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("event stream for %s interrupted: %v; reconnecting", h.org, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (h *eventHub) stream(ctx context.Context, checkpoint *client.InMemoryCheckpointer, resume *bool) error {
+	gw, err := gateways.Get(h.org)
+	if err != nil {
+		return err
+	}
+
+	var options []client.ChaincodeEventsOption
+	if *resume {
+		options = append(options, client.WithCheckpoint(checkpoint))
+	}
+
+	events, err := gw.GetNetwork(settings.Channel).ChaincodeEvents(ctx, settings.Chaincode, options...)
+	if err != nil {
+		return err
+	}
+
+	for event := range events {
+		checkpoint.CheckpointChaincodeEvent(event)
+		*resume = true
+		h.broadcast(event)
+	}
+
+	return fmt.Errorf("event stream closed")
+}
+
+// resumeCheckpoint restarts an event stream after a given transaction in a block
+type resumeCheckpoint struct {
+	blockNumber   uint64
+	transactionID string
+}
+
+func (r *resumeCheckpoint) BlockNumber() uint64 {
+	return r.blockNumber
+}
+
+func (r *resumeCheckpoint) TransactionID() string {
+	return r.transactionID
+}
+
+// parseResumePoint reads where a client wants to resume from: the SSE Last-Event-ID
+// header ("<block>:<txID>", sent automatically by EventSource on reconnect) or a
+// ?startBlock= query parameter. It returns nil when the client wants live events only.
+func parseResumePoint(c *gin.Context) (*resumeCheckpoint, error) {
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		block, txID, _ := strings.Cut(lastEventID, ":")
+		blockNumber, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Last-Event-ID")
+		}
+		return &resumeCheckpoint{blockNumber: blockNumber, transactionID: txID}, nil
+	}
+
+	if startBlock := c.Query("startBlock"); startBlock != "" {
+		blockNumber, err := strconv.ParseUint(startBlock, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid startBlock")
+		}
+		return &resumeCheckpoint{blockNumber: blockNumber}, nil
+	}
+
+	return nil, nil
+}
+
+// eventFilter combines the org's allowed events with an optional ?types=A,B narrowing
+func eventFilter(org string, types string) func(name string) bool {
+	allowed := orgEventTypes[org]
+	var requested map[string]bool
+	if types != "" {
+		requested = map[string]bool{}
+		for _, name := range strings.Split(types, ",") {
+			requested[strings.TrimSpace(name)] = true
+		}
+	}
+
+	return func(name string) bool {
+		return (allowed == nil || allowed[name]) && (requested == nil || requested[name])
+	}
+}
+
+// streamEvents writes chaincode events to the client as Server-Sent Events until either side closes
+func streamEvents(c *gin.Context, events <-chan *client.ChaincodeEvent, include func(name string) bool) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !include(event.EventName) {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %d:%s\nevent: %s\ndata: %s\n\n",
+				event.BlockNumber, event.TransactionID, event.EventName, event.Payload)
+		}
+		c.Writer.Flush()
+	}
+}
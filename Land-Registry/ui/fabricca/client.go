@@ -0,0 +1,350 @@
+// Package fabricca is a small client for the Fabric CA server REST API. It registers,
+// enrolls, re-enrolls and revokes identities.
+package fabricca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client talks to one CA on a Fabric CA server
+type Client struct {
+	URL        string // e.g. https://localhost:7054
+	CAName     string // empty for the server's default CA
+	HTTPClient *http.Client
+}
+
+// New returns a client for the CA at url. tlsCert is the CA server's TLS certificate
+// and may be nil for plain HTTP.
+func New(url, caName string, tlsCert *x509.Certificate) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsCert != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(tlsCert)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/"),
+		CAName:     caName,
+		HTTPClient: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// Signer is an enrolled identity that authorizes requests such as register and revoke
+type Signer struct {
+	Certificate []byte // PEM
+	PrivateKey  crypto.Signer
+}
+
+// Attribute is stored with a registered identity; ECert attributes are put into its certificates
+type Attribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	ECert bool   `json:"ecert,omitempty"`
+}
+
+// AttributeRequest asks for a registered attribute to be put into an enrollment certificate
+type AttributeRequest struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// RegistrationRequest describes a new identity
+type RegistrationRequest struct {
+	Name           string      `json:"id"`
+	Type           string      `json:"type,omitempty"` // client, peer, admin, ...
+	Secret         string      `json:"secret,omitempty"`
+	MaxEnrollments int         `json:"max_enrollments,omitempty"`
+	Affiliation    string      `json:"affiliation"`
+	Attributes     []Attribute `json:"attrs,omitempty"`
+	CAName         string      `json:"caname,omitempty"`
+}
+
+// RevocationRequest revokes every certificate of an identity, or one certificate by AKI and serial
+type RevocationRequest struct {
+	Name   string `json:"id,omitempty"`
+	AKI    string `json:"aki,omitempty"`
+	Serial string `json:"serial,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	CAName string `json:"caname,omitempty"`
+}
+
+// Enrollment is a newly issued certificate with the private key generated for it
+type Enrollment struct {
+	Certificate []byte // PEM
+	PrivateKey  []byte // PKCS#8 PEM
+	CAChain     []byte // PEM
+}
+
+// Error is a failure reported by the CA server
+type Error struct {
+	StatusCode int
+	Messages   []ResponseMessage
+}
+
+// ResponseMessage is one entry of a CA server response's errors or messages
+type ResponseMessage struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("fabric CA request failed with HTTP %d", e.StatusCode)
+	}
+	parts := make([]string, len(e.Messages))
+	for i, message := range e.Messages {
+		parts[i] = fmt.Sprintf("code %d: %s", message.Code, message.Message)
+	}
+	return "fabric CA request failed: " + strings.Join(parts, "; ")
+}
+
+// Register creates an identity and returns its enrollment secret
+func (c *Client) Register(ctx context.Context, registrar *Signer, request *RegistrationRequest) (string, error) {
+	if request.CAName == "" {
+		request.CAName = c.CAName
+	}
+
+	var result struct {
+		Secret string `json:"secret"`
+	}
+	if err := c.post(ctx, "register", request, registrar, nil, &result); err != nil {
+		return "", err
+	}
+	return result.Secret, nil
+}
+
+// Enroll issues a certificate for a registered identity using its enrollment secret
+func (c *Client) Enroll(ctx context.Context, enrollmentID, secret string, attributes []AttributeRequest) (*Enrollment, error) {
+	return c.enroll(ctx, "enroll", enrollmentID, attributes, nil, func(req *http.Request) error {
+		req.SetBasicAuth(enrollmentID, secret)
+		return nil
+	})
+}
+
+// Reenroll issues a fresh certificate and key for an enrolled identity, e.g. before
+// its certificate expires
+func (c *Client) Reenroll(ctx context.Context, current *Signer, attributes []AttributeRequest) (*Enrollment, error) {
+	cert, err := parseCertificate(current.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	return c.enroll(ctx, "reenroll", cert.Subject.CommonName, attributes, current, nil)
+}
+
+// Revoke revokes an identity's certificates so it can no longer transact
+func (c *Client) Revoke(ctx context.Context, registrar *Signer, request *RevocationRequest) error {
+	if request.CAName == "" {
+		request.CAName = c.CAName
+	}
+	return c.post(ctx, "revoke", request, registrar, nil, nil)
+}
+
+func (c *Client) enroll(ctx context.Context, endpoint, commonName string, attributes []AttributeRequest,
+	signer *Signer, authorize func(*http.Request) error) (*Enrollment, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+
+	request := struct {
+		CSR        string             `json:"certificate_request"`
+		CAName     string             `json:"caname,omitempty"`
+		Attributes []AttributeRequest `json:"attr_reqs,omitempty"`
+	}{
+		CSR:        string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+		CAName:     c.CAName,
+		Attributes: attributes,
+	}
+
+	var result struct {
+		Cert       string `json:"Cert"`
+		ServerInfo struct {
+			CAChain string `json:"CAChain"`
+		} `json:"ServerInfo"`
+	}
+	if err := c.post(ctx, endpoint, request, signer, authorize, &result); err != nil {
+		return nil, err
+	}
+
+	certPEM, err := base64.StdEncoding.DecodeString(result.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate: %w", err)
+	}
+	chainPEM, err := base64.StdEncoding.DecodeString(result.ServerInfo.CAChain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CA chain: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	return &Enrollment{
+		Certificate: certPEM,
+		PrivateKey:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		CAChain:     chainPEM,
+	}, nil
+}
+
+// post sends a JSON request, authorized either by a signer's token or by authorize,
+// and decodes the "result" of the CA's response envelope into result
+func (c *Client) post(ctx context.Context, endpoint string, body interface{}, signer *Signer,
+	authorize func(*http.Request) error, result interface{}) error {
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/api/v1/"+endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	switch {
+	case signer != nil:
+		token, err := authToken(signer, req.Method, req.URL.RequestURI(), payload)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", token)
+	case authorize != nil:
+		if err := authorize(req); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach fabric CA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read fabric CA response: %w", err)
+	}
+
+	var envelope struct {
+		Success bool              `json:"success"`
+		Result  json.RawMessage   `json:"result"`
+		Errors  []ResponseMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return &Error{StatusCode: resp.StatusCode, Messages: []ResponseMessage{{Message: strings.TrimSpace(string(data))}}}
+	}
+	if !envelope.Success || resp.StatusCode >= 300 {
+		return &Error{StatusCode: resp.StatusCode, Messages: envelope.Errors}
+	}
+
+	if result != nil && len(envelope.Result) > 0 {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return fmt.Errorf("failed to parse fabric CA response: %w", err)
+		}
+	}
+	return nil
+}
+
+// authToken builds Fabric CA's identity token: the base64 certificate and an ECDSA
+// signature over method, URI, body and certificate
+func authToken(signer *Signer, method, uri string, body []byte) (string, error) {
+	b64Cert := base64.StdEncoding.EncodeToString(signer.Certificate)
+	payload := method + "." +
+		base64.StdEncoding.EncodeToString([]byte(uri)) + "." +
+		base64.StdEncoding.EncodeToString(body) + "." +
+		b64Cert
+	digest := sha256.Sum256([]byte(payload))
+
+	key, ok := signer.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", errors.New("only ECDSA keys can sign fabric CA requests")
+	}
+	signature, err := signLowS(key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign fabric CA request: %w", err)
+	}
+
+	return b64Cert + "." + base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// signLowS signs with the low-S form of the signature, which Fabric requires
+func signLowS(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+	halfOrder := new(big.Int).Rsh(key.Curve.Params().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(key.Curve.Params().N, s)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("failed to parse certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// attributesOID is the certificate extension in which Fabric CA stores ECert attributes
+var attributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// Attributes returns the attributes Fabric CA put into a certificate
+func Attributes(certPEM []byte) (map[string]string, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(attributesOID) {
+			continue
+		}
+		var value struct {
+			Attrs map[string]string `json:"attrs"`
+		}
+		if err := json.Unmarshal(ext.Value, &value); err != nil {
+			return nil, fmt.Errorf("failed to parse certificate attributes: %w", err)
+		}
+		return value.Attrs, nil
+	}
+	return map[string]string{}, nil
+}
+
+// Expiry returns when a certificate stops being valid
+func Expiry(certPEM []byte) (time.Time, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
@@ -0,0 +1,494 @@
+package fabricca
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubCA is a minimal Fabric CA server: it signs CSRs with a self-signed CA key and
+// checks the authorization of every request the way the real server does
+type stubCA struct {
+	t       *testing.T
+	key     *ecdsa.PrivateKey
+	cert    *x509.Certificate
+	certPEM []byte
+
+	secrets map[string]string            // enrollment ID -> secret
+	attrs   map[string]map[string]string // enrollment ID -> ECert attributes
+	revoked map[string]bool
+	serial  int64
+}
+
+func newStubCA(t *testing.T) (*stubCA, *Client) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca.org1.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &stubCA{
+		t:       t,
+		key:     key,
+		cert:    cert,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		secrets: map[string]string{},
+		attrs:   map[string]map[string]string{},
+		revoked: map[string]bool{},
+		serial:  1,
+	}
+	server := httptest.NewServer(ca)
+	t.Cleanup(server.Close)
+	return ca, New(server.URL+"/", "ca-org1", nil)
+}
+
+func (ca *stubCA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		ca.fail(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v1/enroll":
+		id, secret, ok := r.BasicAuth()
+		if !ok || ca.secrets[id] != secret {
+			ca.fail(w, http.StatusUnauthorized, 20, "Authentication failure")
+			return
+		}
+		ca.issue(w, id, body)
+	case "/api/v1/reenroll":
+		caller, ok := ca.authenticate(w, r, body)
+		if !ok {
+			return
+		}
+		ca.issue(w, caller, body)
+	case "/api/v1/register":
+		if _, ok := ca.authenticate(w, r, body); !ok {
+			return
+		}
+		var request RegistrationRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			ca.fail(w, http.StatusBadRequest, 0, err.Error())
+			return
+		}
+		if _, exists := ca.secrets[request.Name]; exists {
+			ca.fail(w, http.StatusBadRequest, 74, "Identity '"+request.Name+"' is already registered")
+			return
+		}
+		secret := request.Secret
+		if secret == "" {
+			secret = "generated-secret"
+		}
+		ca.secrets[request.Name] = secret
+		ca.attrs[request.Name] = map[string]string{}
+		for _, attribute := range request.Attributes {
+			if attribute.ECert {
+				ca.attrs[request.Name][attribute.Name] = attribute.Value
+			}
+		}
+		ca.succeed(w, map[string]string{"secret": secret})
+	case "/api/v1/revoke":
+		if _, ok := ca.authenticate(w, r, body); !ok {
+			return
+		}
+		var request RevocationRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			ca.fail(w, http.StatusBadRequest, 0, err.Error())
+			return
+		}
+		ca.revoked[request.Name] = true
+		ca.succeed(w, map[string]interface{}{"RevokedCerts": []interface{}{}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authenticate verifies a token built by authToken and returns the caller's common name
+func (ca *stubCA) authenticate(w http.ResponseWriter, r *http.Request, body []byte) (string, bool) {
+	parts := strings.Split(r.Header.Get("Authorization"), ".")
+	if len(parts) != 2 {
+		ca.fail(w, http.StatusUnauthorized, 20, "Authorization header is not a token")
+		return "", false
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		ca.fail(w, http.StatusUnauthorized, 20, "bad certificate")
+		return "", false
+	}
+	cert, err := parseCertificate(certPEM)
+	if err != nil || cert.CheckSignatureFrom(ca.cert) != nil {
+		ca.fail(w, http.StatusUnauthorized, 20, "certificate was not issued by this CA")
+		return "", false
+	}
+	if ca.revoked[cert.Subject.CommonName] {
+		ca.fail(w, http.StatusUnauthorized, 20, "certificate has been revoked")
+		return "", false
+	}
+
+	payload := r.Method + "." +
+		base64.StdEncoding.EncodeToString([]byte(r.URL.RequestURI())) + "." +
+		base64.StdEncoding.EncodeToString(body) + "." +
+		parts[0]
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || !ecdsa.VerifyASN1(cert.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		ca.fail(w, http.StatusUnauthorized, 20, "token signature is invalid")
+		return "", false
+	}
+	return cert.Subject.CommonName, true
+}
+
+func (ca *stubCA) issue(w http.ResponseWriter, id string, body []byte) {
+	var request struct {
+		CSR    string `json:"certificate_request"`
+		CAName string `json:"caname"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		ca.fail(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	if request.CAName != "ca-org1" {
+		ca.fail(w, http.StatusBadRequest, 19, "CA '"+request.CAName+"' does not exist")
+		return
+	}
+	block, _ := pem.Decode([]byte(request.CSR))
+	if block == nil {
+		ca.fail(w, http.StatusBadRequest, 0, "bad certificate request")
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil || csr.CheckSignature() != nil {
+		ca.fail(w, http.StatusBadRequest, 0, "bad certificate request")
+		return
+	}
+
+	attrs, err := json.Marshal(map[string]interface{}{"attrs": ca.attrs[id]})
+	if err != nil {
+		ca.fail(w, http.StatusInternalServerError, 0, err.Error())
+		return
+	}
+	ca.serial++
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(ca.serial),
+		Subject:         pkix.Name{CommonName: id},
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: attributesOID, Value: attrs}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		ca.fail(w, http.StatusInternalServerError, 0, err.Error())
+		return
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	ca.succeed(w, map[string]interface{}{
+		"Cert":       base64.StdEncoding.EncodeToString(certPEM),
+		"ServerInfo": map[string]string{"CAChain": base64.StdEncoding.EncodeToString(ca.certPEM)},
+	})
+}
+
+func (ca *stubCA) succeed(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true, "result": result, "errors": []interface{}{}, "messages": []interface{}{},
+	}); err != nil {
+		ca.t.Error(err)
+	}
+}
+
+func (ca *stubCA) fail(w http.ResponseWriter, status int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false, "result": nil,
+		"errors":   []ResponseMessage{{Code: code, Message: message}},
+		"messages": []interface{}{},
+	}); err != nil {
+		ca.t.Error(err)
+	}
+}
+
+// signer turns an enrollment into the Signer that authorizes later requests
+func signer(t *testing.T, enrollment *Enrollment) *Signer {
+	block, _ := pem.Decode(enrollment.PrivateKey)
+	if block == nil {
+		t.Fatal("enrollment private key is not PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{Certificate: enrollment.Certificate, PrivateKey: key.(*ecdsa.PrivateKey)}
+}
+
+func enrollAdmin(t *testing.T, ca *stubCA, client *Client) *Signer {
+	ca.secrets["admin"] = "adminpw"
+	enrollment, err := client.Enroll(context.Background(), "admin", "adminpw", nil)
+	if err != nil {
+		t.Fatalf("Enroll admin: %v", err)
+	}
+	return signer(t, enrollment)
+}
+
+func TestEnroll(t *testing.T) {
+	ca, client := newStubCA(t)
+	ca.secrets["seller1"] = "s3cret"
+
+	enrollment, err := client.Enroll(context.Background(), "seller1", "s3cret", nil)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+
+	cert, err := parseCertificate(enrollment.Certificate)
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	if cert.Subject.CommonName != "seller1" {
+		t.Errorf("common name = %q, want seller1", cert.Subject.CommonName)
+	}
+	if err := cert.CheckSignatureFrom(ca.cert); err != nil {
+		t.Errorf("certificate not issued by the CA: %v", err)
+	}
+	if string(enrollment.CAChain) != string(ca.certPEM) {
+		t.Error("CA chain does not match the CA certificate")
+	}
+
+	key := signer(t, enrollment).PrivateKey.(*ecdsa.PrivateKey)
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		t.Error("private key does not match the certificate")
+	}
+}
+
+func TestEnrollWrongSecret(t *testing.T) {
+	ca, client := newStubCA(t)
+	ca.secrets["seller1"] = "s3cret"
+
+	_, err := client.Enroll(context.Background(), "seller1", "wrong", nil)
+	var caErr *Error
+	if !errors.As(err, &caErr) {
+		t.Fatalf("Enroll error = %v, want *Error", err)
+	}
+	if caErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", caErr.StatusCode, http.StatusUnauthorized)
+	}
+	if len(caErr.Messages) != 1 || caErr.Messages[0].Code != 20 {
+		t.Errorf("messages = %+v, want code 20", caErr.Messages)
+	}
+	if !strings.Contains(err.Error(), "Authentication failure") {
+		t.Errorf("error = %q, want the CA's message", err.Error())
+	}
+}
+
+func TestRegisterAndEnrollWithAttributes(t *testing.T) {
+	ca, client := newStubCA(t)
+	admin := enrollAdmin(t, ca, client)
+
+	secret, err := client.Register(context.Background(), admin, &RegistrationRequest{
+		Name:        "registrar1",
+		Type:        "client",
+		Affiliation: "org3.department1",
+		Attributes:  []Attribute{{Name: "role", Value: "registrar", ECert: true}, {Name: "hidden", Value: "x"}},
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if secret != "generated-secret" {
+		t.Errorf("secret = %q, want the CA's generated secret", secret)
+	}
+
+	enrollment, err := client.Enroll(context.Background(), "registrar1", secret, []AttributeRequest{{Name: "role"}})
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	attrs, err := Attributes(enrollment.Certificate)
+	if err != nil {
+		t.Fatalf("Attributes: %v", err)
+	}
+	if attrs["role"] != "registrar" {
+		t.Errorf("role = %q, want registrar", attrs["role"])
+	}
+	if _, ok := attrs["hidden"]; ok {
+		t.Error("attribute without ecert was put into the certificate")
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	ca, client := newStubCA(t)
+	admin := enrollAdmin(t, ca, client)
+
+	_, err := client.Register(context.Background(), admin, &RegistrationRequest{Name: "admin", Affiliation: "org1"})
+	var caErr *Error
+	if !errors.As(err, &caErr) || caErr.Messages[0].Code != 74 {
+		t.Fatalf("Register error = %v, want code 74", err)
+	}
+}
+
+func TestReenroll(t *testing.T) {
+	ca, client := newStubCA(t)
+	admin := enrollAdmin(t, ca, client)
+
+	enrollment, err := client.Reenroll(context.Background(), admin, nil)
+	if err != nil {
+		t.Fatalf("Reenroll: %v", err)
+	}
+	renewed := signer(t, enrollment)
+	if string(renewed.Certificate) == string(admin.Certificate) {
+		t.Error("re-enrollment returned the old certificate")
+	}
+	if renewed.PrivateKey.(*ecdsa.PrivateKey).Equal(admin.PrivateKey) {
+		t.Error("re-enrollment reused the old key")
+	}
+	cert, err := parseCertificate(renewed.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "admin" {
+		t.Errorf("common name = %q, want admin", cert.Subject.CommonName)
+	}
+
+	// the new identity can authorize requests
+	if _, err := client.Register(context.Background(), renewed, &RegistrationRequest{Name: "buyer1", Affiliation: "org2"}); err != nil {
+		t.Errorf("Register with re-enrolled identity: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ca, client := newStubCA(t)
+	admin := enrollAdmin(t, ca, client)
+
+	ca.secrets["buyer1"] = "pw"
+	enrollment, err := client.Enroll(context.Background(), "buyer1", "pw", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Revoke(context.Background(), admin, &RevocationRequest{Name: "buyer1", Reason: "keycompromise"}); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if !ca.revoked["buyer1"] {
+		t.Fatal("identity was not revoked")
+	}
+
+	_, err = client.Reenroll(context.Background(), signer(t, enrollment), nil)
+	var caErr *Error
+	if !errors.As(err, &caErr) || caErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Reenroll after revoke error = %v, want HTTP 401", err)
+	}
+}
+
+func TestTamperedTokenRejected(t *testing.T) {
+	ca, client := newStubCA(t)
+	admin := enrollAdmin(t, ca, client)
+
+	// a token signed for a different body must not authorize this one
+	token, err := authToken(admin, http.MethodPost, "/api/v1/register", []byte(`{"id":"other"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.post(context.Background(), "register", &RegistrationRequest{Name: "buyer1", Affiliation: "org2"}, nil,
+		func(req *http.Request) error {
+			req.Header.Set("Authorization", token)
+			return nil
+		}, nil)
+	var caErr *Error
+	if !errors.As(err, &caErr) || !strings.Contains(caErr.Error(), "signature is invalid") {
+		t.Errorf("error = %v, want an invalid signature", err)
+	}
+}
+
+func TestSignLowS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	halfOrder := new(big.Int).Rsh(key.Curve.Params().N, 1)
+	digest := sha256.Sum256([]byte("payload"))
+
+	for i := 0; i < 32; i++ {
+		signature, err := signLowS(key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+			t.Fatal("signature does not verify")
+		}
+		var parsed struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		if parsed.S.Cmp(halfOrder) > 0 {
+			t.Fatal("signature S is in the high half of the curve order")
+		}
+	}
+}
+
+func TestNonJSONResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, "", nil).Enroll(context.Background(), "seller1", "pw", nil)
+	var caErr *Error
+	if !errors.As(err, &caErr) {
+		t.Fatalf("error = %v, want *Error", err)
+	}
+	if caErr.StatusCode != http.StatusBadGateway || caErr.Messages[0].Message != "bad gateway" {
+		t.Errorf("error = %+v, want HTTP 502 with the response body", caErr)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	ca, client := newStubCA(t)
+	ca.secrets["seller1"] = "pw"
+	enrollment, err := client.Enroll(context.Background(), "seller1", "pw", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiry, err := Expiry(enrollment.Certificate)
+	if err != nil {
+		t.Fatalf("Expiry: %v", err)
+	}
+	if until := time.Until(expiry); until <= 0 || until > time.Hour {
+		t.Errorf("expiry = %v, want within the next hour", expiry)
+	}
+
+	if _, err := Expiry([]byte("not a certificate")); err == nil {
+		t.Error("Expiry accepted a non-PEM certificate")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// gatewayPool keeps one gRPC connection per org peer and one long-lived Gateway per
+// signing identity on top of it. Gateways are safe for concurrent use, so every
+// request and event stream for the same identity shares one.
+type gatewayPool struct {
+	mu       sync.Mutex
+	conns    map[string]*grpc.ClientConn // by org
+	gateways map[string]*signerGateway   // by signer key
+	closed   bool
+}
+
+type signerGateway struct {
+	org     string
+	gateway *client.Gateway
+}
+
+var gateways = &gatewayPool{conns: map[string]*grpc.ClientConn{}, gateways: map[string]*signerGateway{}}
+
+// Get returns the gateway for the org's own service identity from its profile.
+// It is used for backend work that belongs to no user, such as event streams.
+func (p *gatewayPool) Get(org string) (*client.Gateway, error) {
+	return p.get("org:"+org, func() (string, identity.Identity, identity.Sign, error) {
+		orgProfile, ok := profile[org]
+		if !ok {
+			return "", nil, nil, fmt.Errorf("unknown org %q", org)
+		}
+		id, err := newIdentity(orgProfile.CertPath, orgProfile.MSPID)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		sign, err := newSign(orgProfile.KeyDirectory)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return org, id, sign, nil
+	})
+}
+
+// ForIdentity returns the gateway that signs as the wallet identity with the given label
+func (p *gatewayPool) ForIdentity(label string) (*client.Gateway, error) {
+	return p.get("wallet:"+label, func() (string, identity.Identity, identity.Sign, error) {
+		walletID, err := userWallet.Get(label)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		id, err := walletID.identity()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		sign, err := walletID.sign()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return walletID.Org, id, sign, nil
+	})
+}
+
+// Forget closes the gateway for a wallet identity, e.g. after it was re-enrolled or revoked
+func (p *gatewayPool) Forget(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if signerGw, ok := p.gateways["wallet:"+label]; ok {
+		signerGw.gateway.Close()
+		delete(p.gateways, "wallet:"+label)
+	}
+}
+
+// get returns the gateway for a signer, connecting on first use or when the org's
+// connection has failed
+func (p *gatewayPool) get(key string, load func() (string, identity.Identity, identity.Sign, error)) (*client.Gateway, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, connectionError(fmt.Errorf("gateway pool is shut down"))
+	}
+
+	if existing, ok := p.gateways[key]; ok {
+		if healthy(p.conns[existing.org]) {
+			return existing.gateway, nil
+		}
+		p.dropOrg(existing.org)
+	}
+
+	org, id, sign, err := load()
+	if err != nil {
+		return nil, connectionError(err)
+	}
+
+	conn, ok := p.conns[org]
+	if ok && !healthy(conn) {
+		p.dropOrg(org)
+		ok = false
+	}
+	if !ok {
+		if conn, err = dialOrg(org); err != nil {
+			return nil, err
+		}
+		p.conns[org] = conn
+	}
+
+	gw, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(conn),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+	if err != nil {
+		return nil, connectionError(err)
+	}
+	p.gateways[key] = &signerGateway{org: org, gateway: gw}
+
+	return gw, nil
+}
+
+// dropOrg closes an org's failed connection and every gateway using it
+func (p *gatewayPool) dropOrg(org string) {
+	if conn, ok := p.conns[org]; ok {
+		log.Printf("gateway connection for %s is %s; reconnecting", org, conn.GetState())
+		conn.Close()
+		delete(p.conns, org)
+	}
+	for key, signerGw := range p.gateways {
+		if signerGw.org == org {
+			signerGw.gateway.Close()
+			delete(p.gateways, key)
+		}
+	}
+}
+
+// Close shuts every gateway down; later calls to Get fail
+func (p *gatewayPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, signerGw := range p.gateways {
+		signerGw.gateway.Close()
+		delete(p.gateways, key)
+	}
+	for org, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, org)
+	}
+	p.closed = true
+}
+
+// healthy reports whether a connection can still carry requests. An idle connection
+// is woken up; one in TransientFailure is rebuilt rather than left to back off.
+func healthy(conn *grpc.ClientConn) bool {
+	if conn == nil {
+		return false
+	}
+	switch conn.GetState() {
+	case connectivity.Shutdown, connectivity.TransientFailure:
+		return false
+	case connectivity.Idle:
+		conn.Connect()
+	}
+	return true
+}
+
+// dialOrg connects to the org's gateway peer
+func dialOrg(org string) (*grpc.ClientConn, error) {
+	orgProfile, ok := profile[org]
+	if !ok {
+		return nil, connectionError(fmt.Errorf("unknown org %q", org))
+	}
+
+	tlsCert, err := orgProfile.tlsCertificate()
+	if err != nil {
+		return nil, connectionError(err)
+	}
+	clientConn, err := newGrpcConnection(tlsCert, orgProfile.GatewayPeer, orgProfile.PeerEndpoint)
+	if err != nil {
+		return nil, connectionError(err)
+	}
+
+	return clientConn, nil
+}
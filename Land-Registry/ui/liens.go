@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+)
+
+// LienConsent mirrors the chaincode's LienConsent. Field order and tags must
+// match, since the chaincode verifies the signature over its own marshaling.
+type LienConsent struct {
+	LienID    string `json:"lienID"`
+	LandID    string `json:"landID"`
+	Action    string `json:"action"`            // ListLand, RegisterToBuyer or TransferShare
+	OfferID   string `json:"offerID,omitempty"` // the accepted offer, for RegisterToBuyer
+	Expiry    string `json:"expiry"`            // RFC3339
+	Nonce     string `json:"nonce"`             // makes the consent single-use
+	Signature string `json:"signature,omitempty"`
+}
+
+// signLienConsent signs a consent with the lienholder's wallet key: ECDSA over
+// the SHA-256 of the consent's JSON without a signature
+func signLienConsent(id *walletIdentity, consent *LienConsent) error {
+	consent.Signature = ""
+	payload, err := json.Marshal(consent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal consent: %w", err)
+	}
+
+	sign, err := id.sign()
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(payload)
+	signature, err := sign(digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign consent: %w", err)
+	}
+
+	consent.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// lienTransient passes lienholders' consents to the chaincode, or nothing when
+// there are none
+func lienTransient(consents []LienConsent) (map[string][]byte, error) {
+	if len(consents) == 0 {
+		return map[string][]byte{}, nil
+	}
+	consentsJSON, err := json.Marshal(consents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lien consents: %w", err)
+	}
+	return map[string][]byte{"lienConsents": consentsJSON}, nil
+}
+
+// sameCertificate reports whether two PEM certificates hold the same DER bytes
+func sameCertificate(a string, b string) bool {
+	blockA, _ := pem.Decode([]byte(a))
+	blockB, _ := pem.Decode([]byte(b))
+	return blockA != nil && blockB != nil && bytes.Equal(blockA.Bytes, blockB.Bytes)
+}
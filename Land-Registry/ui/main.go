@@ -1,21 +1,59 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
 )
 
 func main() {
+	// Org profiles, channel and chaincode come from config.yaml and LAND_REGISTRY_* variables
+	if err := loadSettings(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	if err := setupAuth(); err != nil {
+		log.Fatalf("failed to set up users: %v", err)
+	}
+
+	// Account and identity management commands run instead of the server
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "add-user":
+			err = addUserCommand(os.Args[2:])
+		case "enroll":
+			err = enrollCommand(os.Args[2:])
+		default:
+			log.Fatalf("unknown command %q; use add-user or enroll", os.Args[1])
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	router := gin.Default()
 
 	// Allow requests from browser frontend
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5500"},
 		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		AllowCredentials: true,
 	}))
 
@@ -28,19 +66,61 @@ func main() {
 
 	// ========== API ENDPOINTS ==========
 
-	// Org1 - List Land
-	router.POST("/api/list-land", func(c *gin.Context) {
+	// Anyone - Log in with a username and password; the session comes back as a JWT and a cookie
+	router.POST("/api/login", login)
+	router.POST("/api/logout", logout)
+
+	// Anyone, e.g. a bank or court - Check a buyer's ownership record against the hash
+	// the Registry stored, without logging in or reading the private collection
+	router.POST("/api/ownership/verify", func(c *gin.Context) {
+		var body struct {
+			LandID string          `json:"landID"`
+			Record json.RawMessage `json:"record"` // the ownership record as handed over by its holder
+		}
+		if err := c.BindJSON(&body); err != nil || body.LandID == "" || len(body.Record) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := evaluateAsOrg(settings.VerifierOrg, "VerifyOwnershipRecord", body.LandID, string(body.Record))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Every other endpoint acts as the logged-in user and signs with their own identity
+	api := router.Group("/api", requireUser)
+
+	api.GET("/me", func(c *gin.Context) {
+		user := currentUser(c)
+		c.JSON(http.StatusOK, gin.H{"username": user.Username, "org": user.Org, "role": user.Role, "identity": user.Identity})
+	})
+
+	// Onboarding through the org's Fabric CA
+	router.POST("/api/ca/enroll", enrollIdentity)
+	api.POST("/ca/reenroll", reenrollIdentity)
+	api.POST("/ca/register", requireAdmin, registerIdentity)
+	api.POST("/ca/revoke", requireAdmin, revokeIdentity)
+
+	// Org1 - List Land (or re-list by the current owner)
+	api.POST("/list-land", func(c *gin.Context) {
 		var land struct {
-			LandID       string `json:"landID"`
-			Location     string `json:"location"`
-			Size         string `json:"size"`
-			Type         string `json:"type"`
-			SoilQuality  string `json:"soilQuality"`
-			WaterSource  string `json:"waterSource"`
-			NearbyRoad   string `json:"nearbyRoad"`
-			NearbyCity   string `json:"nearbyCity"`
-			Coordinates  string `json:"coordinates"`
-			SellingPrice string `json:"sellingPrice"`
+			LandID      string `json:"landID"`
+			Location    string `json:"location"`
+			Area        string `json:"area"`
+			AreaUnit    string `json:"areaUnit"`
+			Type        string `json:"type"`
+			SoilQuality string `json:"soilQuality"`
+			WaterSource string `json:"waterSource"`
+			NearbyRoad  string `json:"nearbyRoad"`
+			NearbyCity  string `json:"nearbyCity"`
+			Coordinates string `json:"coordinates"`
+			PriceMinor  string `json:"priceMinor"` // selling price in paise
+
+			LienConsents []LienConsent `json:"lienConsents"` // needed to re-list land with active liens
 		}
 
 		if err := c.BindJSON(&land); err != nil {
@@ -48,20 +128,295 @@ func main() {
 			return
 		}
 
-		result := submitTxnFn("org1", "autochannel", "Land-Registry", "LandContract", "invoke",
-			map[string][]byte{},
+		privateData, err := lienTransient(land.LienConsents)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode lien consents"})
+			return
+		}
+
+		txnType := "invoke"
+		if len(land.LienConsents) > 0 {
+			txnType = "private"
+		}
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", txnType,
+			privateData,
 			"ListLand",
-			land.LandID, land.Location, land.Size, land.Type, land.SoilQuality,
-			land.WaterSource, land.NearbyRoad, land.NearbyCity, land.Coordinates, land.SellingPrice,
+			land.LandID, land.Location, land.Area, land.AreaUnit, land.Type, land.SoilQuality,
+			land.WaterSource, land.NearbyRoad, land.NearbyCity, land.Coordinates, land.PriceMinor,
 		)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner - Delist Land
+	api.POST("/delist-land", func(c *gin.Context) {
+		var body struct {
+			LandID string `json:"landID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "DelistLand", body.LandID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner - Update Selling Price
+	api.POST("/update-price", func(c *gin.Context) {
+		var body struct {
+			LandID     string `json:"landID"`
+			PriceMinor string `json:"priceMinor"` // selling price in paise
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "UpdateSellingPrice", body.LandID, body.PriceMinor)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org1 - Submit Seller Title documents to the Registry
+	api.POST("/submit-seller-title", func(c *gin.Context) {
+		var body struct {
+			LandID      string            `json:"landID"`
+			SellerTitle map[string]string `json:"sellerTitle"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		privateData := map[string][]byte{
+			"sellerTitle": encodeJSONBytes(body.SellerTitle),
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			privateData, "SubmitSellerTitle", body.LandID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org3 - Migrate free-form size/price records, one batch at a time
+	api.POST("/migrate-lands", func(c *gin.Context) {
+		var body struct {
+			StartKey  string `json:"startKey"`
+			BatchSize string `json:"batchSize"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if body.BatchSize == "" {
+			body.BatchSize = "100"
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "MigrateLandRecords", body.StartKey, body.BatchSize)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org3 - Assign the owner of a land written before owners were tracked
+	api.POST("/assign-legacy-owner", func(c *gin.Context) {
+		var body struct {
+			LandID   string `json:"landID"`
+			Username string `json:"username"` // the owner
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		ownerID, ownerMSP, ok := userClientID(body.Username)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.Username})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "AssignLegacyOwner", body.LandID, ownerID, ownerMSP)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Org configuration: which MSP IDs act as seller, buyer and registry
+	api.GET("/org-config", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetOrgConfig")
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Registry admin - Change the org configuration
+	api.POST("/org-config", func(c *gin.Context) {
+		configJSON, err := c.GetRawData()
+		if err != nil || !json.Valid(configJSON) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "SetOrgConfig", string(configJSON))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner - Ask the registry to split a parcel into children whose areas add up to it
+	api.POST("/split-request", func(c *gin.Context) {
+		var body struct {
+			RequestID string            `json:"requestID"`
+			ParentID  string            `json:"parentID"`
+			Children  []json.RawMessage `json:"children"` // {landID, area, areaUnit, location?, coordinates?}
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		childrenJSON, err := json.Marshal(body.Children)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode child parcels"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "RequestSplit", body.RequestID, body.ParentID, string(childrenJSON))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner - Ask the registry to merge parcels they own into a new parcel
+	api.POST("/merge-request", func(c *gin.Context) {
+		var body struct {
+			RequestID string   `json:"requestID"`
+			LandIDs   []string `json:"landIDs"`
+			NewID     string   `json:"newID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		landIDsJSON, err := json.Marshal(body.LandIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode land IDs"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "RequestMerge", body.RequestID, string(landIDsJSON), body.NewID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner or Org3 - Withdraw or refuse a pending split or merge request
+	api.POST("/parcel-request/cancel", func(c *gin.Context) {
+		var body struct {
+			RequestID string `json:"requestID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "CancelParcelRequest", body.RequestID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get a split or merge request
+	api.GET("/parcel-request/:id", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetParcelRequest", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Org3 - Approve a split or merge request; ownership records are carried over to the new parcels
+	api.POST("/split-land", approveParcelRequest("SplitLand"))
+	api.POST("/merge-lands", approveParcelRequest("MergeLands"))
+
+	// Org3 - Verify Seller Title
+	api.POST("/verify-seller-title", func(c *gin.Context) {
+		var body struct {
+			LandID string `json:"landID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "VerifySellerTitle", body.LandID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
 
 		c.String(http.StatusOK, result)
 	})
 
 	// Org2 - Get Available Lands
-	router.GET("/api/get-available-lands", func(c *gin.Context) {
-		result := submitTxnFn("org2", "autochannel", "Land-Registry", "LandContract", "query",
+	api.GET("/get-available-lands", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
 			map[string][]byte{}, "GetAvailableLands")
+		if err != nil {
+			respondError(c, err)
+			return
+		}
 
 		var parsed []map[string]interface{}
 		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
@@ -72,50 +427,1479 @@ func main() {
 		c.JSON(http.StatusOK, parsed)
 	})
 
+	// Anyone - Search Lands a page at a time
+	// e.g. /api/lands/search?nearbyCity=Kochi&minPrice=10000000&minSize=1&sizeUnit=acre&pageSize=20&bookmark=...
+	api.GET("/lands/search", func(c *gin.Context) {
+		filter := map[string]interface{}{}
+		for _, key := range []string{"nearbyCity", "type", "waterSource", "status", "sizeUnit"} {
+			if value := c.Query(key); value != "" {
+				filter[key] = value
+			}
+		}
+		for _, key := range []string{"minPrice", "maxPrice"} {
+			if value := c.Query(key); value != "" {
+				number, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+					return
+				}
+				filter[key] = number
+			}
+		}
+		for _, key := range []string{"minSize", "maxSize"} {
+			if value := c.Query(key); value != "" {
+				number, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+					return
+				}
+				filter[key] = number
+			}
+		}
+
+		pageSize := c.DefaultQuery("pageSize", "20")
+		if _, err := strconv.ParseInt(pageSize, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pageSize"})
+			return
+		}
+
+		filterJSON, err := json.Marshal(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode filter"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "SearchLands", string(filterJSON), pageSize, c.Query("bookmark"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse lands"})
+			return
+		}
+
+		c.JSON(http.StatusOK, parsed)
+	})
+
 	// Org2 - Request to Buy
-	router.POST("/api/request-buy", func(c *gin.Context) {
+	api.POST("/request-buy", func(c *gin.Context) {
 		var body struct {
 			OfferID      string            `json:"offerID"`
 			BuyerRequest map[string]string `json:"buyerRequest"`
+			CoBuyers     []struct {
+				Username         string `json:"username"`
+				BuyerName        string `json:"buyerName"`
+				Aadhar           string `json:"aadhar"`
+				ShareBasisPoints int    `json:"shareBasisPoints"`
+			} `json:"coBuyers"` // buying jointly; the buyer keeps the remaining share
+			OnBehalfOf string `json:"onBehalfOf"` // username of the buyer, when acting as their attorney
 		}
 		if err := c.BindJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 			return
 		}
+		if body.OnBehalfOf != "" {
+			principalID, _, ok := userClientID(body.OnBehalfOf)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.OnBehalfOf})
+				return
+			}
+			if body.BuyerRequest == nil {
+				body.BuyerRequest = map[string]string{}
+			}
+			body.BuyerRequest["onBehalfOf"] = principalID
+		}
 
-		privateData := map[string][]byte{
-			"buyerRequest": encodeJSONBytes(body.BuyerRequest),
+		request := map[string]interface{}{}
+		for key, value := range body.BuyerRequest {
+			request[key] = value
+		}
+		// The offered price is in paise, passed on as a number
+		if value, ok := body.BuyerRequest["priceMinor"]; ok {
+			number, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priceMinor"})
+				return
+			}
+			request["priceMinor"] = number
+		}
+		if len(body.CoBuyers) > 0 {
+			coBuyers := make([]map[string]interface{}, 0, len(body.CoBuyers))
+			for _, coBuyer := range body.CoBuyers {
+				coBuyerID, coBuyerMSP, ok := userClientID(coBuyer.Username)
+				if !ok {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown co-buyer " + coBuyer.Username})
+					return
+				}
+				coBuyers = append(coBuyers, map[string]interface{}{
+					"buyerID":          coBuyerID,
+					"buyerMSP":         coBuyerMSP,
+					"buyerName":        coBuyer.BuyerName,
+					"aadhar":           coBuyer.Aadhar,
+					"shareBasisPoints": coBuyer.ShareBasisPoints,
+				})
+			}
+			request["coBuyers"] = coBuyers
+		}
+		requestJSON, err := json.Marshal(request)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode request"})
+			return
 		}
+		privateData := map[string][]byte{"buyerRequest": requestJSON}
 
-		result := submitTxnFn("org2", "autochannel", "Land-Registry", "LandContract", "private",
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
 			privateData, "RequestToBuy", body.OfferID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
 
 		c.String(http.StatusOK, result)
 	})
 
-	// Org3 - Register to Buyer
-	router.POST("/api/register-buyer", func(c *gin.Context) {
+	// Owner - Accept Offer
+	api.POST("/accept-offer", func(c *gin.Context) {
 		var body struct {
-			LandID         string            `json:"landID"`
-			BuyerOwnership map[string]string `json:"buyerOwnership"`
+			OfferID string `json:"offerID"`
 		}
 		if err := c.BindJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 			return
 		}
 
-		privateData := map[string][]byte{
-			"buyerOwnership": encodeJSONBytes(body.BuyerOwnership),
+		privateData, err := presentOffer(currentUser(c).Identity, body.OfferID)
+		if err != nil {
+			respondError(c, err)
+			return
 		}
 
-		result := submitTxnFn("org3", "autochannel", "Land-Registry", "LandContract", "private",
-			privateData, "RegisterToBuyer", body.LandID)
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			privateData, "AcceptOffer", body.OfferID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner - Reject Offer
+	api.POST("/reject-offer", func(c *gin.Context) {
+		var body struct {
+			OfferID string `json:"offerID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "RejectOffer", body.OfferID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org2 - Withdraw Offer
+	api.POST("/withdraw-offer", func(c *gin.Context) {
+		var body struct {
+			OfferID string `json:"offerID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		privateData, err := presentOffer(currentUser(c).Identity, body.OfferID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			privateData, "WithdrawOffer", body.OfferID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
 
 		c.String(http.StatusOK, result)
 	})
 
-	// Start server on localhost:3001
-	router.Run("localhost:3001")
+	// Owner - Start a sealed-bid auction for a listed land
+	api.POST("/auction/start", func(c *gin.Context) {
+		var body struct {
+			AuctionID         string `json:"auctionID"`
+			LandID            string `json:"landID"`
+			ReservePriceMinor string `json:"reservePriceMinor"` // in paise
+			BiddingEnds       string `json:"biddingEnds"`       // RFC3339
+			RevealEnds        string `json:"revealEnds"`        // RFC3339
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "invoke",
+			map[string][]byte{}, "StartAuction", body.AuctionID, body.LandID, body.ReservePriceMinor, body.BiddingEnds, body.RevealEnds)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org2 - Commit a sealed bid; only its hash is public until it is revealed
+	api.POST("/auction/bid", func(c *gin.Context) {
+		var body struct {
+			AuctionID string `json:"auctionID"`
+			BidID     string `json:"bidID"`
+			Bid       struct {
+				PriceMinor int64  `json:"priceMinor"` // in paise
+				Salt       string `json:"salt"`       // generated when empty
+				BuyerName  string `json:"buyerName"`
+				Aadhar     string `json:"aadhar"`
+			} `json:"bid"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if body.Bid.Salt == "" {
+			salt := make([]byte, 16)
+			if _, err := rand.Read(salt); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seal bid"})
+				return
+			}
+			body.Bid.Salt = hex.EncodeToString(salt)
+		}
+		bidJSON, err := json.Marshal(body.Bid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seal bid"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "private",
+			map[string][]byte{"bid": bidJSON}, "CommitBid", body.AuctionID, body.BidID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org2 - Reveal one's own bid once bidding has ended
+	api.POST("/auction/reveal", func(c *gin.Context) {
+		var body struct {
+			AuctionID string `json:"auctionID"`
+			BidID     string `json:"bidID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		bid, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "query",
+			map[string][]byte{}, "GetBid", body.AuctionID, body.BidID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "private",
+			map[string][]byte{"bid": []byte(bid)}, "RevealBid", body.AuctionID, body.BidID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner, registrar or bidder - Close the auction after the reveal period; the winning bid becomes the accepted offer
+	api.POST("/auction/close", func(c *gin.Context) {
+		var body struct {
+			AuctionID string `json:"auctionID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// Registrars and losing bidders cannot read the winning bid; the auction
+		// then closes without it and the seller or winner records it later
+		privateData := map[string][]byte{}
+		if currentUser(c).Role != "registrar" {
+			winningBid, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "query",
+				map[string][]byte{}, "GetWinningBid", body.AuctionID)
+			var txnErr *TxnError
+			if err != nil && !(errors.As(err, &txnErr) && txnErr.Status == http.StatusForbidden) {
+				respondError(c, err)
+				return
+			}
+			if err == nil && winningBid != "" {
+				privateData["winningBid"] = []byte(winningBid)
+			}
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "private",
+			privateData, "CloseAuction", body.AuctionID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner or winning bidder - Record the winning bid of an auction closed without it
+	api.POST("/auction/record-winner", func(c *gin.Context) {
+		var body struct {
+			AuctionID string `json:"auctionID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		winningBid, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "query",
+			map[string][]byte{}, "GetWinningBid", body.AuctionID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "private",
+			map[string][]byte{"winningBid": []byte(winningBid)}, "RecordWinningBid", body.AuctionID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get an auction and its bid commitments
+	api.GET("/auction/:id", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "query",
+			map[string][]byte{}, "GetAuction", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	api.GET("/auction/:id/bids", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "AuctionContract", "query",
+			map[string][]byte{}, "GetAuctionBids", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Anyone - Get Land History (every version, with txID and timestamp)
+	api.GET("/land/:id/history", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetLandHistory", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		var parsed []map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse land history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, parsed)
+	})
+
+	// Anyone - Get a parcel's ancestry through splits and merges
+	api.GET("/land/:id/lineage", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetLandLineage", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Owner - Agree to a lender registering a lien on their land
+	api.POST("/liens/approve", func(c *gin.Context) {
+		var body struct {
+			LandID      string `json:"landID"`
+			LienID      string `json:"lienID"`
+			Lender      string `json:"lender"`      // username of the lender
+			AmountMinor string `json:"amountMinor"` // in paise
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		lenderID, lenderMSP, ok := userClientID(body.Lender)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.Lender})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "ApproveLien", body.LandID, body.LienID, lenderID, lenderMSP, body.AmountMinor)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Lender - Register a lien the owner has approved
+	api.POST("/liens/register", func(c *gin.Context) {
+		var body struct {
+			LandID      string `json:"landID"`
+			LienID      string `json:"lienID"`
+			AmountMinor string `json:"amountMinor"` // in paise
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "RegisterLien", body.LandID, body.LienID, body.AmountMinor)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Lienholder - Release a lien
+	api.POST("/liens/release", func(c *gin.Context) {
+		var body struct {
+			LandID string `json:"landID"`
+			LienID string `json:"lienID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "ReleaseLien", body.LandID, body.LienID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Lienholder - Sign consent to a re-listing or transfer, for the owner or
+	// registrar to pass with their request
+	api.POST("/liens/consent", func(c *gin.Context) {
+		var body struct {
+			LandID  string `json:"landID"`
+			LienID  string `json:"lienID"`
+			Action  string `json:"action"`  // ListLand, RegisterToBuyer or TransferShare
+			OfferID string `json:"offerID"` // the accepted offer, for RegisterToBuyer
+			Expiry  string `json:"expiry"`  // RFC3339, default 24 hours from now
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if body.Action != "ListLand" && body.Action != "RegisterToBuyer" && body.Action != "TransferShare" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "action must be ListLand, RegisterToBuyer or TransferShare"})
+			return
+		}
+		if body.Expiry == "" {
+			body.Expiry = time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+		}
+
+		label := currentUser(c).Identity
+		lienholder, err := userWallet.Get(label)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load identity"})
+			return
+		}
+
+		liensResult, err := submitTxnFn(label, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetLiens", body.LandID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		var liens []struct {
+			LienID     string `json:"lienID"`
+			LenderCert string `json:"lenderCert"`
+		}
+		if err := json.Unmarshal([]byte(liensResult), &liens); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse liens"})
+			return
+		}
+		for _, lien := range liens {
+			// The chaincode checks consents against the certificate stored with the lien,
+			// so bring it up to date if this identity has been re-enrolled since
+			if lien.LienID == body.LienID && !sameCertificate(lien.LenderCert, lienholder.Credentials.Certificate) {
+				_, err := submitTxnFn(label, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+					map[string][]byte{}, "UpdateLienholderCertificate", body.LandID, body.LienID)
+				if err != nil {
+					respondError(c, err)
+					return
+				}
+			}
+		}
+
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign consent"})
+			return
+		}
+		consent := LienConsent{
+			LienID:  body.LienID,
+			LandID:  body.LandID,
+			Action:  body.Action,
+			OfferID: body.OfferID,
+			Expiry:  body.Expiry,
+			Nonce:   hex.EncodeToString(nonce),
+		}
+		if err := signLienConsent(lienholder, &consent); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, consent)
+	})
+
+	// Anyone - Get the liens on a land in priority order
+	api.GET("/land/:id/liens", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetLiens", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Judiciary - Freeze a land under a court order
+	api.POST("/court/freeze", func(c *gin.Context) {
+		var body struct {
+			LandID       string `json:"landID"`
+			OrderID      string `json:"orderID"`
+			CaseRef      string `json:"caseRef"`
+			DocumentHash string `json:"documentHash"`
+			Expiry       string `json:"expiry"` // RFC3339
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "FreezeLand", body.LandID, body.OrderID, body.CaseRef, body.DocumentHash, body.Expiry)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Judiciary - Lift a court order
+	api.POST("/court/unfreeze", func(c *gin.Context) {
+		var body struct {
+			LandID  string `json:"landID"`
+			OrderID string `json:"orderID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "UnfreezeLand", body.LandID, body.OrderID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get the court orders in force on a land
+	api.GET("/land/:id/orders", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetActiveOrders", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Owner - Offer a lease on land to another user without transferring title
+	api.POST("/lease/register", func(c *gin.Context) {
+		var body struct {
+			LandID       string `json:"landID"`
+			LeaseID      string `json:"leaseID"`
+			Lessee       string `json:"lessee"` // username of the lessee
+			LesseeName   string `json:"lesseeName"`
+			Start        string `json:"start"` // RFC3339
+			End          string `json:"end"`   // RFC3339
+			RentMinor    int64  `json:"rentMinor"`
+			RentPeriod   string `json:"rentPeriod"`
+			RenewalTerms string `json:"renewalTerms"`
+			MaxRenewals  int    `json:"maxRenewals"`
+			DepositHash  string `json:"depositHash"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// The chaincode names the lessee by identity, so look up theirs
+		lesseeID, lesseeMSP, ok := userClientID(body.Lessee)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown lessee"})
+			return
+		}
+
+		terms, err := json.Marshal(map[string]interface{}{
+			"lesseeID":     lesseeID,
+			"lesseeMSP":    lesseeMSP,
+			"lesseeName":   body.LesseeName,
+			"start":        body.Start,
+			"end":          body.End,
+			"rentMinor":    body.RentMinor,
+			"rentPeriod":   body.RentPeriod,
+			"renewalTerms": body.RenewalTerms,
+			"maxRenewals":  body.MaxRenewals,
+			"depositHash":  body.DepositHash,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode lease terms"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			map[string][]byte{"lease": terms}, "RegisterLease", body.LandID, body.LeaseID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Lessee - Accept a lease offered by the owner, which then takes effect
+	api.POST("/lease/accept", func(c *gin.Context) {
+		var body struct {
+			LeaseID string `json:"leaseID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		transient, err := presentLease(currentUser(c).Identity, body.LeaseID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			transient, "AcceptLease", body.LeaseID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner or lessee - Renew a lease within its renewal terms
+	api.POST("/lease/renew", func(c *gin.Context) {
+		var body struct {
+			LeaseID string `json:"leaseID"`
+			NewEnd  string `json:"newEnd"` // RFC3339
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		transient, err := presentLease(currentUser(c).Identity, body.LeaseID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			transient, "RenewLease", body.LeaseID, body.NewEnd)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner or lessee - End a lease early
+	api.POST("/lease/terminate", func(c *gin.Context) {
+		var body struct {
+			LeaseID string `json:"leaseID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		transient, err := presentLease(currentUser(c).Identity, body.LeaseID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			transient, "TerminateLease", body.LeaseID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Owner or lessee - Get a lease's terms
+	api.GET("/lease/:id", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetLease", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Org3 - Open a succession on a deceased holder's share of a land
+	api.POST("/succession/initiate", func(c *gin.Context) {
+		var body struct {
+			LandID               string `json:"landID"`
+			SuccessionID         string `json:"successionID"`
+			Deceased             string `json:"deceased"` // username of the deceased holder
+			DeathCertificateHash string `json:"deathCertificateHash"`
+			SuccessionDocHash    string `json:"successionDocHash"`
+			Heirs                []struct {
+				Username         string `json:"username"`
+				ShareBasisPoints int    `json:"shareBasisPoints"`
+			} `json:"heirs"` // the first heir takes the deceased's place
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		deceasedID, _, ok := userClientID(body.Deceased)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown deceased holder " + body.Deceased})
+			return
+		}
+
+		heirs := make([]map[string]interface{}, 0, len(body.Heirs))
+		for _, heir := range body.Heirs {
+			heirID, heirMSP, ok := userClientID(heir.Username)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown heir " + heir.Username})
+				return
+			}
+			heirs = append(heirs, map[string]interface{}{
+				"ownerID":          heirID,
+				"ownerMSP":         heirMSP,
+				"shareBasisPoints": heir.ShareBasisPoints,
+			})
+		}
+		heirsJSON, err := json.Marshal(heirs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode heirs"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "InitiateSuccession",
+			body.LandID, body.SuccessionID, deceasedID, body.DeathCertificateHash, body.SuccessionDocHash, string(heirsJSON))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Object to a pending succession
+	api.POST("/succession/object", func(c *gin.Context) {
+		var body struct {
+			SuccessionID string `json:"successionID"`
+			ObjectionID  string `json:"objectionID"`
+			GroundsHash  string `json:"groundsHash"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "RaiseObjection", body.SuccessionID, body.ObjectionID, body.GroundsHash)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org3 - Uphold or dismiss an objection
+	api.POST("/succession/resolve", func(c *gin.Context) {
+		var body struct {
+			SuccessionID string `json:"successionID"`
+			ObjectionID  string `json:"objectionID"`
+			Upheld       bool   `json:"upheld"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "ResolveObjection", body.SuccessionID, body.ObjectionID, strconv.FormatBool(body.Upheld))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org3 - Pass the deceased's share to the heirs once the objection window has ended
+	api.POST("/succession/finalize", func(c *gin.Context) {
+		var body struct {
+			SuccessionID string `json:"successionID"`
+			Holders      []struct {
+				Username string `json:"username"`
+				Name     string `json:"name"`
+				Aadhar   string `json:"aadhar"`
+			} `json:"holders"` // heirs, and any holder not named in the current ownership record
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		details := make([]map[string]interface{}, 0, len(body.Holders))
+		for _, holder := range body.Holders {
+			holderID, _, ok := userClientID(holder.Username)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown holder " + holder.Username})
+				return
+			}
+			details = append(details, map[string]interface{}{
+				"ownerID": holderID,
+				"name":    holder.Name,
+				"aadhar":  holder.Aadhar,
+			})
+		}
+		detailsJSON, err := json.Marshal(details)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode holder details"})
+			return
+		}
+
+		successionJSON, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetSuccession", body.SuccessionID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		var succession struct {
+			LandID string `json:"landID"`
+		}
+		if err := json.Unmarshal([]byte(successionJSON), &succession); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse succession"})
+			return
+		}
+		privateData, err := presentOwnershipRecords(currentUser(c).Identity, []string{succession.LandID})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		privateData["holderDetails"] = detailsJSON
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			privateData, "FinalizeSuccession", body.SuccessionID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Org3 - Withdraw a pending succession
+	api.POST("/succession/cancel", func(c *gin.Context) {
+		var body struct {
+			SuccessionID string `json:"successionID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "CancelSuccession", body.SuccessionID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get a succession and the objections to it
+	api.GET("/succession/:id", func(c *gin.Context) {
+		succession, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetSuccession", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		objections, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetObjections", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"succession": json.RawMessage(succession),
+			"objections": json.RawMessage(objections),
+		})
+	})
+
+	// Joint holder or their attorney - Consent to selling the land under its accepted offer
+	api.POST("/sale-consent", func(c *gin.Context) {
+		var body struct {
+			LandID  string `json:"landID"`
+			OfferID string `json:"offerID"`
+			Holder  string `json:"holder"` // username of the holder, when acting as their attorney
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		holderID := ""
+		if body.Holder != "" {
+			clientID, _, ok := userClientID(body.Holder)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.Holder})
+				return
+			}
+			holderID = clientID
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "ConsentToSale", body.LandID, body.OfferID, holderID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get the holders' consents to a land's accepted offer
+	api.GET("/land/:id/sale-consents", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetSaleConsents", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Recipient - Accept a share of a land a holder is to transfer to the caller
+	api.POST("/share/accept", func(c *gin.Context) {
+		var body struct {
+			LandID           string `json:"landID"`
+			From             string `json:"from"` // username of the transferring holder
+			ShareBasisPoints int    `json:"shareBasisPoints"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		fromID, _, ok := userClientID(body.From)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.From})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "AcceptShareTransfer", body.LandID, fromID, strconv.Itoa(body.ShareBasisPoints))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Co-buyer - Confirm buying a share of a land jointly under an offer
+	api.POST("/offer/confirm-cobuy", func(c *gin.Context) {
+		var body struct {
+			LandID           string `json:"landID"`
+			OfferID          string `json:"offerID"`
+			ShareBasisPoints int    `json:"shareBasisPoints"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "ConfirmCoBuy", body.LandID, body.OfferID, strconv.Itoa(body.ShareBasisPoints))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Holder or their attorney - Transfer part or all of a share of a jointly held land to another user
+	api.POST("/transfer-share", func(c *gin.Context) {
+		var body struct {
+			LandID           string        `json:"landID"`
+			Username         string        `json:"username"` // the recipient
+			ShareBasisPoints int           `json:"shareBasisPoints"`
+			Holder           string        `json:"holder"`       // username of the holder, when acting as their attorney
+			LienConsents     []LienConsent `json:"lienConsents"` // needed while the land has active liens
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		toID, toMSP, ok := userClientID(body.Username)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.Username})
+			return
+		}
+		holderID := ""
+		if body.Holder != "" {
+			clientID, _, ok := userClientID(body.Holder)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.Holder})
+				return
+			}
+			holderID = clientID
+		}
+
+		privateData, err := lienTransient(body.LienConsents)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode lien consents"})
+			return
+		}
+
+		txnType := "invoke"
+		if len(body.LienConsents) > 0 {
+			txnType = "private"
+		}
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", txnType,
+			privateData, "TransferShare",
+			body.LandID, toID, toMSP, strconv.Itoa(body.ShareBasisPoints), holderID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get the lands a user holds, whole or in part; no username means the caller
+	api.GET("/holdings", func(c *gin.Context) {
+		ownerID := ""
+		if username := c.Query("username"); username != "" {
+			clientID, _, ok := userClientID(username)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + username})
+				return
+			}
+			ownerID = clientID
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetHoldings", ownerID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Owner, holder or buyer - Give another user power of attorney over a land
+	api.POST("/poa/grant", func(c *gin.Context) {
+		var body struct {
+			LandID  string   `json:"landID"`
+			GrantID string   `json:"grantID"`
+			Agent   string   `json:"agent"`  // username of the attorney
+			Scopes  []string `json:"scopes"` // list, accept, transfer, buy
+			Expiry  string   `json:"expiry"` // RFC3339
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		agentID, agentMSP, ok := userClientID(body.Agent)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user " + body.Agent})
+			return
+		}
+		scopesJSON, err := json.Marshal(body.Scopes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode scopes"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "GrantPowerOfAttorney",
+			body.LandID, body.GrantID, agentID, agentMSP, string(scopesJSON), body.Expiry)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Principal - Revoke a power of attorney
+	api.POST("/poa/revoke", func(c *gin.Context) {
+		var body struct {
+			LandID  string `json:"landID"`
+			GrantID string `json:"grantID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "invoke",
+			map[string][]byte{}, "RevokePowerOfAttorney", body.LandID, body.GrantID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Get the powers of attorney granted over a land
+	api.GET("/land/:id/poa", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetPowersOfAttorney", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Anyone - Get what attorneys have done on a land
+	api.GET("/land/:id/agent-actions", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetAgentActions", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Owner or Org2 - Get Offers for a Land
+	api.GET("/land/:id/offers", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetOffersForLand", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		var parsed []map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse offers"})
+			return
+		}
+
+		c.JSON(http.StatusOK, parsed)
+	})
+
+	// Owner or Org3 - Get the private ownership record, to hand to a verifier
+	api.GET("/land/:id/ownership-record", func(c *gin.Context) {
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetOwnershipRecord", c.Param("id"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", []byte(result))
+	})
+
+	// Org3 - Register to Buyer
+	api.POST("/register-buyer", func(c *gin.Context) {
+		var body struct {
+			LandID       string        `json:"landID"`
+			DocumentHash string        `json:"documentHash"`
+			LienConsents []LienConsent `json:"lienConsents"` // needed while the land has active liens
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// The registry cannot read the offer itself, so fetch it on the seller's behalf
+		landResult, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetLandByID", body.LandID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		var land struct {
+			Owner           string `json:"owner"`
+			AcceptedOfferID string `json:"acceptedOfferID"`
+		}
+		if err := json.Unmarshal([]byte(landResult), &land); err != nil || land.AcceptedOfferID == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Land has no accepted offer"})
+			return
+		}
+
+		seller, ok := walletLabelForClientID(land.Owner)
+		if !ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "The seller's identity is not held by this server"})
+			return
+		}
+
+		offerResult, err := submitTxnFn(seller, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetOffer", land.AcceptedOfferID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		privateData, err := lienTransient(body.LienConsents)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode lien consents"})
+			return
+		}
+		privateData["acceptedOffer"] = []byte(offerResult)
+		privateData["documentHash"] = []byte(body.DocumentHash)
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			privateData, "RegisterToBuyer", body.LandID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	})
+
+	// Anyone - Live chaincode events as Server-Sent Events
+	// e.g. /api/events?types=LandListed,OwnershipTransferred&startBlock=120
+	api.GET("/events", func(c *gin.Context) {
+		org := currentUser(c).Org
+
+		resume, err := parseResumePoint(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		include := eventFilter(org, c.Query("types"))
+
+		// Live clients share the org's stream; resuming clients replay from their own
+		if resume == nil {
+			events, unsubscribe := eventHubFor(org).subscribe()
+			defer unsubscribe()
+			streamEvents(c, events, include)
+			return
+		}
+
+		gw, err := gateways.Get(org)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+
+		events, err := gw.GetNetwork(settings.Channel).ChaincodeEvents(c.Request.Context(), settings.Chaincode,
+			client.WithCheckpoint(resume))
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+
+		streamEvents(c, events, include)
+	})
+
+	// Start server on the configured address and shut down cleanly on SIGINT/SIGTERM
+	shutdown, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Requests derive from this context, so open event streams end when shutdown begins
+	requests, cancelRequests := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        settings.ListenAddress,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return requests },
+	}
+	server.RegisterOnShutdown(cancelRequests)
+
+	// Keep users' certificates from expiring
+	go renewIdentities(requests)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server failed: %v", err)
+		}
+	}()
+
+	<-shutdown.Done()
+	log.Println("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	gateways.Close()
+}
+
+// presentOffer reads an offer as the given identity and returns it as the "offer"
+// transient entry. Registry peers endorse changes to the land but cannot read the
+// offer, so the chaincode checks the presented copy against its hash instead.
+func presentOffer(identityLabel string, offerID string) (map[string][]byte, error) {
+	offer, err := submitTxnFn(identityLabel, settings.Channel, settings.Chaincode, "LandContract", "query",
+		map[string][]byte{}, "GetOffer", offerID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"offer": []byte(offer)}, nil
+}
+
+// presentLease reads a lease as the given identity and returns it as the "lease"
+// transient entry, for the same reason as presentOffer
+func presentLease(identityLabel string, leaseID string) (map[string][]byte, error) {
+	lease, err := submitTxnFn(identityLabel, settings.Channel, settings.Chaincode, "LandContract", "query",
+		map[string][]byte{}, "GetLease", leaseID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"lease": []byte(lease)}, nil
+}
+
+// approveParcelRequest has a registrar carry out a split or merge request with
+// SplitLand or MergeLands, presenting the ownership records of the lands involved
+func approveParcelRequest(txnName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			RequestID string `json:"requestID"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		requestJSON, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetParcelRequest", body.RequestID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		var request struct {
+			LandIDs []string `json:"landIDs"`
+		}
+		if err := json.Unmarshal([]byte(requestJSON), &request); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse parcel request"})
+			return
+		}
+		privateData, err := presentOwnershipRecords(currentUser(c).Identity, request.LandIDs)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		result, err := submitTxnFn(currentUser(c).Identity, settings.Channel, settings.Chaincode, "LandContract", "private",
+			privateData, txnName, body.RequestID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.String(http.StatusOK, result)
+	}
+}
+
+// presentOwnershipRecords reads the ownership records of lands as the given
+// identity and returns them as the "ownershipRecords" transient entry. Lands that
+// were never transferred have no record and are left out.
+func presentOwnershipRecords(identityLabel string, landIDs []string) (map[string][]byte, error) {
+	records := []json.RawMessage{}
+	for _, landID := range landIDs {
+		record, err := submitTxnFn(identityLabel, settings.Channel, settings.Chaincode, "LandContract", "query",
+			map[string][]byte{}, "GetOwnershipRecord", landID)
+		var txnErr *TxnError
+		if errors.As(err, &txnErr) && txnErr.Status == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, json.RawMessage(record))
+	}
+
+	recordsJSON, err := json.Marshal(records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ownership records: %w", err)
+	}
+	return map[string][]byte{"ownershipRecords": recordsJSON}, nil
 }
 
 // Utility function for transient data
@@ -1,41 +1,69 @@
 package main
 
+import (
+	"os"
+	"path/filepath"
+)
+
 type Config struct {
-	CryptoPath   string `json:"cryptoPath"`
-	CertPath     string `json:"certPath"`
-	KeyDirectory string `json:"keyPath"`
-	TLSCertPath  string `json:"tlsCertPath"`
-	PeerEndpoint string `json:"peerEndpoint"`
-	GatewayPeer  string `json:"gatewayPeer"`
-	MSPID        string `json:"mspID"`
+	CryptoPath        string `json:"cryptoPath" yaml:"cryptoPath"`
+	CertPath          string `json:"certPath" yaml:"certPath"`
+	KeyDirectory      string `json:"keyPath" yaml:"keyPath"`
+	TLSCertPath       string `json:"tlsCertPath" yaml:"tlsCertPath"`
+	PeerEndpoint      string `json:"peerEndpoint" yaml:"peerEndpoint"`
+	GatewayPeer       string `json:"gatewayPeer" yaml:"gatewayPeer"`
+	MSPID             string `json:"mspID" yaml:"mspID"`
+	ConnectionProfile string `json:"connectionProfile" yaml:"connectionProfile"` // optional Fabric CCP file
+	CAURL             string `json:"caURL" yaml:"caURL"`                         // Fabric CA used to onboard users
+	CAName            string `json:"caName" yaml:"caName"`
+	CATLSCertPath     string `json:"caTLSCertPath" yaml:"caTLSCertPath"`
+	CARegistrar       string `json:"caRegistrar" yaml:"caRegistrar"` // wallet identity allowed to register users
+
+	tlsCertPEM []byte // inline TLS CA certificate taken from a connection profile
 }
 
-var profile = map[string]Config{
-	"org1": {
-		CryptoPath:   "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org1.example.com/",
-		CertPath:     "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/signcerts/cert.pem",
-		KeyDirectory: "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/keystore/",
-		TLSCertPath:  "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org1.example.com/peers/peer0.org1.example.com/tls/ca.crt",
-		PeerEndpoint: "localhost:7051",
-		GatewayPeer:  "peer0.org1.example.com",
-		MSPID:        "Org1MSP",
-	},
-	"org2": {
-		CryptoPath:   "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org2.example.com/",
-		CertPath:     "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org2.example.com/users/User1@org2.example.com/msp/signcerts/cert.pem",
-		KeyDirectory: "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org2.example.com/users/User1@org2.example.com/msp/keystore/",
-		TLSCertPath:  "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org2.example.com/peers/peer0.org2.example.com/tls/ca.crt",
-		PeerEndpoint: "localhost:9051",
-		GatewayPeer:  "peer0.org2.example.com",
-		MSPID:        "Org2MSP",
-	},
-	"org3": {
-		CryptoPath:   "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org3.example.com/",
-		CertPath:     "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org3.example.com/users/User1@org3.example.com/msp/signcerts/cert.pem",
-		KeyDirectory: "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org3.example.com/users/User1@org3.example.com/msp/keystore/",
-		TLSCertPath:  "/home/lenovo/CHF/fabric-samples/test-network/organizations/peerOrganizations/org3.example.com/peers/peer0.org3.example.com/tls/ca.crt",
-		PeerEndpoint: "localhost:11051",
-		GatewayPeer:  "peer0.org3.example.com",
-		MSPID:        "Org3MSP",
-	},
+// profile holds the org profiles in use, keyed by org name (org1, org2, ...). It is
+// filled by loadSettings at startup and read-only afterwards.
+var profile = map[string]Config{}
+
+// testNetworkProfiles describes the fabric-samples test network with User1 of each org,
+// used when no config file names the orgs.
+func testNetworkProfiles(testNetworkDir string) map[string]Config {
+	orgs := map[string]struct {
+		domain   string
+		mspID    string
+		endpoint string
+		caURL    string
+		caDir    string
+	}{
+		"org1": {"org1.example.com", "Org1MSP", "localhost:7051", "https://localhost:7054", "organizations/fabric-ca/org1"},
+		"org2": {"org2.example.com", "Org2MSP", "localhost:9051", "https://localhost:8054", "organizations/fabric-ca/org2"},
+		"org3": {"org3.example.com", "Org3MSP", "localhost:11051", "https://localhost:11054", "addOrg3/fabric-ca/org3"},
+	}
+
+	profiles := map[string]Config{}
+	for name, org := range orgs {
+		cryptoPath := filepath.Join(testNetworkDir, "organizations", "peerOrganizations", org.domain)
+		userMSP := filepath.Join(cryptoPath, "users", "User1@"+org.domain, "msp")
+		orgProfile := Config{
+			CryptoPath:   cryptoPath,
+			CertPath:     filepath.Join(userMSP, "signcerts", "cert.pem"),
+			KeyDirectory: filepath.Join(userMSP, "keystore"),
+			TLSCertPath:  filepath.Join(cryptoPath, "peers", "peer0."+org.domain, "tls", "ca.crt"),
+			PeerEndpoint: org.endpoint,
+			GatewayPeer:  "peer0." + org.domain,
+			MSPID:        org.mspID,
+		}
+
+		// Only a network started with -ca has a Fabric CA for onboarding users
+		caTLSCert := filepath.Join(testNetworkDir, org.caDir, "tls-cert.pem")
+		if _, err := os.Stat(caTLSCert); err == nil {
+			orgProfile.CAURL = org.caURL
+			orgProfile.CAName = "ca-" + name
+			orgProfile.CATLSCertPath = caTLSCert
+			orgProfile.CARegistrar = name + "-admin"
+		}
+		profiles[name] = orgProfile
+	}
+	return profiles
 }
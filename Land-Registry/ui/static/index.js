@@ -40,18 +40,104 @@ document.getElementById("listLandForm").addEventListener("submit", async (e) =>
   document.getElementById("registerBuyerForm").addEventListener("submit", async (e) => {
     e.preventDefault();
     const form = e.target;
-    const { landID, ...ownership } = Object.fromEntries(new FormData(form).entries());
+    const data = Object.fromEntries(new FormData(form).entries());
   
     const res = await fetch("/api/register-buyer", {
       method: "POST",
       headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({
-        landID,
-        buyerOwnership: ownership
-      })
+      body: JSON.stringify(data)
     });
   
     document.getElementById("registerBuyerResult").innerText = await res.text();
     form.reset();
   });
-  
\ No newline at end of file
+
+  // Live updates: EventSource reconnects on its own and resumes via Last-Event-ID.
+  // The stream carries the logged-in user's session cookie.
+  let liveEvents = null;
+
+  function startLiveEvents() {
+    if (liveEvents) {
+      liveEvents.close();
+    }
+    liveEvents = new EventSource("/api/events");
+    [
+      "LandListed", "LandDelisted", "LandPriceUpdated",
+      "OfferCreated", "OfferAccepted", "OfferRejected", "OfferWithdrawn",
+      "OwnershipTransferred"
+    ].forEach((name) => {
+      liveEvents.addEventListener(name, (e) => {
+        const event = JSON.parse(e.data);
+        const log = document.getElementById("liveEvents");
+        log.innerText = `${event.timestamp} ${event.type} ${event.landID || ""}\n` + log.innerText;
+
+        if (document.getElementById("availableLands").innerText) {
+          getAvailableLands();
+        }
+      });
+    });
+  }
+
+  function showUser(user) {
+    document.getElementById("loginStatus").innerText = user
+      ? `Logged in as ${user.username} (${user.org})`
+      : "Not logged in";
+  }
+
+  document.getElementById("loginForm").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const form = e.target;
+    const data = Object.fromEntries(new FormData(form).entries());
+
+    const res = await fetch("/api/login", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(data)
+    });
+
+    const result = await res.json();
+    if (!res.ok) {
+      document.getElementById("loginStatus").innerText = result.error;
+      return;
+    }
+    showUser(result);
+    startLiveEvents();
+    form.reset();
+  });
+
+  document.getElementById("enrollForm").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const form = e.target;
+    const data = Object.fromEntries(new FormData(form).entries());
+
+    const res = await fetch("/api/ca/enroll", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(data)
+    });
+
+    const result = await res.json();
+    document.getElementById("enrollResult").innerText = res.ok
+      ? `Enrolled ${result.username} (${result.role || "no role"}); you can log in now`
+      : result.error;
+    form.reset();
+  });
+
+  async function logout() {
+    await fetch("/api/logout", { method: "POST" });
+    if (liveEvents) {
+      liveEvents.close();
+      liveEvents = null;
+    }
+    showUser(null);
+  }
+
+  // Pick up an existing session on page load
+  fetch("/api/me").then(async (res) => {
+    if (res.ok) {
+      showUser(await res.json());
+      startLiveEvents();
+    } else {
+      showUser(null);
+    }
+  });
\ No newline at end of file
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// User is a person who logs in to the backend. Transactions they submit are signed
+// with their own identity from the wallet.
+type User struct {
+	Username     string `json:"username"`
+	Org          string `json:"org"`
+	Identity     string `json:"identity"`       // wallet label
+	Role         string `json:"role,omitempty"` // seller, buyer, registrar, surveyor, admin, lender or judge
+	PasswordHash string `json:"passwordHash"`   // pbkdf2-sha256$<iterations>$<salt>$<key>
+}
+
+// userRoles are the roles a user can hold
+var userRoles = map[string]bool{"seller": true, "buyer": true, "registrar": true, "surveyor": true, "admin": true, "lender": true, "judge": true}
+
+// userStore keeps the backend's users in a JSON file
+type userStore struct {
+	mu    sync.RWMutex
+	path  string
+	users map[string]*User
+}
+
+var users *userStore
+
+func loadUserStore(path string) (*userStore, error) {
+	store := &userStore{path: path, users: map[string]*User{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users file %s: %w", path, err)
+	}
+
+	var list []*User
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse users file %s: %w", path, err)
+	}
+	for _, user := range list {
+		store.users[user.Username] = user
+	}
+	return store, nil
+}
+
+// Get returns the named user
+func (s *userStore) Get(username string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[username]
+	return user, ok
+}
+
+// Put adds or replaces a user and saves the file
+func (s *userStore) Put(user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[user.Username] = user
+	return s.save()
+}
+
+// Remove deletes a user and saves the file
+func (s *userStore) Remove(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, username)
+	return s.save()
+}
+
+func (s *userStore) save() error {
+	list := make([]*User, 0, len(s.users))
+	for _, u := range s.users {
+		list = append(list, u)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode users: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save users file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Authenticate checks a username and password
+func (s *userStore) Authenticate(username, password string) (*User, bool) {
+	user, ok := s.Get(username)
+	if !ok {
+		// Spend the same time as a real check so usernames can't be probed
+		checkPassword(dummyPasswordHash, password)
+		return nil, false
+	}
+	return user, checkPassword(user.PasswordHash, password)
+}
+
+const passwordIterations = 210000
+
+var dummyPasswordHash = hashPasswordWithSalt("", make([]byte, 16), passwordIterations)
+
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hashPasswordWithSalt(password, salt, passwordIterations), nil
+}
+
+func hashPasswordWithSalt(password string, salt []byte, iterations int) string {
+	key := pbkdf2SHA256([]byte(password), salt, iterations, 32)
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", iterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func checkPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got := pbkdf2SHA256([]byte(password), salt, iterations, len(want))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// pbkdf2SHA256 derives a key as in RFC 8018 with HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// addUserCommand creates or updates a login account:
+//
+//	go run . add-user -username alice -org org1 -cert <signcerts/cert.pem> -key <keystore dir>
+//
+// The certificate and key are imported into the wallet under the username. Without
+// -cert an identity already in the wallet is used (-identity, default the username).
+// The password is read from LAND_REGISTRY_PASSWORD or the first line of stdin.
+func addUserCommand(args []string) error {
+	flags := flag.NewFlagSet("add-user", flag.ContinueOnError)
+	username := flags.String("username", "", "login name")
+	org := flags.String("org", "", "org profile the user belongs to, e.g. org1")
+	certPath := flags.String("cert", "", "enrolled certificate to import")
+	keyDirectory := flags.String("key", "", "keystore directory holding the private key")
+	label := flags.String("identity", "", "wallet identity to use instead of importing one")
+	role := flags.String("role", "", "seller, buyer, registrar, surveyor, admin, lender or judge")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" {
+		return fmt.Errorf("-username is required")
+	}
+	orgProfile, ok := profile[*org]
+	if !ok {
+		return fmt.Errorf("-org must name a configured org")
+	}
+	if *label == "" {
+		*label = *username
+	}
+	if *role != "" && !userRoles[*role] {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	if *certPath != "" {
+		id, err := newWalletIdentity(*org, orgProfile.MSPID, *certPath, *keyDirectory)
+		if err != nil {
+			return err
+		}
+		if err := userWallet.Put(*label, id); err != nil {
+			return err
+		}
+	} else if id, err := userWallet.Get(*label); err != nil {
+		return err
+	} else if id.Org != *org {
+		return fmt.Errorf("identity %s belongs to %s, not %s", *label, id.Org, *org)
+	}
+
+	password, ok := os.LookupEnv(envPrefix + "PASSWORD")
+	if !ok {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < 8 {
+		return fmt.Errorf("password must be at least 8 characters")
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return users.Put(&User{Username: *username, Org: *org, Identity: *label, Role: *role, PasswordHash: hash})
+}
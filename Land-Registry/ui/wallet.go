@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// walletIdentity is one user's enrolled X.509 identity, stored in the Fabric SDK
+// file-system wallet format plus the org profile whose peer it connects through
+type walletIdentity struct {
+	Type        string `json:"type"`
+	Version     int    `json:"version"`
+	MSPID       string `json:"mspId"`
+	Org         string `json:"org"`
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+}
+
+// wallet keeps identities as <label>.id files in a directory on the server
+type wallet struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+var userWallet *wallet
+
+func newWallet(dir string) (*wallet, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create wallet directory: %w", err)
+	}
+	return &wallet{dir: dir}, nil
+}
+
+func (w *wallet) path(label string) (string, error) {
+	if label == "" || strings.ContainsAny(label, `/\`) || strings.HasPrefix(label, ".") {
+		return "", fmt.Errorf("invalid identity label %q", label)
+	}
+	return filepath.Join(w.dir, label+".id"), nil
+}
+
+// Get loads the identity stored under label
+func (w *wallet) Get(label string) (*walletIdentity, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	file, err := w.path(label)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("identity %s not found in wallet", label)
+		}
+		return nil, fmt.Errorf("failed to read identity %s: %w", label, err)
+	}
+
+	var id walletIdentity
+	if err := json.Unmarshal(data, &id); err != nil {
+		return nil, fmt.Errorf("failed to parse identity %s: %w", label, err)
+	}
+	return &id, nil
+}
+
+// Put stores an identity under label, replacing any earlier one
+func (w *wallet) Put(label string, id *walletIdentity) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	file, err := w.path(label)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(id, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode identity %s: %w", label, err)
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("failed to store identity %s: %w", label, err)
+	}
+	return nil
+}
+
+// Remove deletes the identity stored under label
+func (w *wallet) Remove(label string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	file, err := w.path(label)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove identity %s: %w", label, err)
+	}
+	return nil
+}
+
+// Labels lists every identity in the wallet
+func (w *wallet) Labels() ([]string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet: %w", err)
+	}
+	var labels []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".id"); ok && !entry.IsDir() {
+			labels = append(labels, name)
+		}
+	}
+	return labels, nil
+}
+
+// newWalletIdentity reads a certificate and the private key from a keystore directory,
+// e.g. a cryptogen or fabric-ca-client MSP folder
+func newWalletIdentity(org, mspID, certPath, keyDirectory string) (*walletIdentity, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	files, err := os.ReadDir(keyDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no private key found in %s", keyDirectory)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(keyDirectory, files[0].Name()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	id := &walletIdentity{Type: "X.509", Version: 1, MSPID: mspID, Org: org}
+	id.Credentials.Certificate = string(certPEM)
+	id.Credentials.PrivateKey = string(keyPEM)
+
+	if _, err := id.identity(); err != nil {
+		return nil, err
+	}
+	if _, err := id.sign(); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (id *walletIdentity) identity() (*identity.X509Identity, error) {
+	certificate, err := identity.CertificateFromPEM([]byte(id.Credentials.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	x509Identity, err := identity.NewX509Identity(id.MSPID, certificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	return x509Identity, nil
+}
+
+func (id *walletIdentity) sign() (identity.Sign, error) {
+	privateKey, err := identity.PrivateKeyFromPEM([]byte(id.Credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+	return sign, nil
+}
+
+// clientID is the identity as chaincode sees it through GetClientIdentity().GetID()
+func (id *walletIdentity) clientID() (string, error) {
+	certificate, err := identity.CertificateFromPEM([]byte(id.Credentials.Certificate))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	x509ID := "x509::" + certificate.Subject.ToRDNSequence().String() + "::" + certificate.Issuer.ToRDNSequence().String()
+	return base64.StdEncoding.EncodeToString([]byte(x509ID)), nil
+}
+
+// walletLabelForClientID finds the wallet identity chaincode knows as clientID
+func walletLabelForClientID(clientID string) (string, bool) {
+	labels, err := userWallet.Labels()
+	if err != nil {
+		return "", false
+	}
+	for _, label := range labels {
+		id, err := userWallet.Get(label)
+		if err != nil {
+			continue
+		}
+		if candidate, err := id.clientID(); err == nil && candidate == clientID {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+// userClientID finds the identity a user acts with, as chaincode sees it, so
+// other users can name them in transactions
+func userClientID(username string) (clientID string, mspID string, ok bool) {
+	user, found := users.Get(username)
+	if !found {
+		return "", "", false
+	}
+	id, err := userWallet.Get(user.Identity)
+	if err != nil {
+		return "", "", false
+	}
+	clientID, err = id.clientID()
+	if err != nil {
+		return "", "", false
+	}
+	return clientID, id.MSPID, true
+}